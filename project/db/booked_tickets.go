@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"tickets/entity"
+)
+
+// BookedTickets returns how many tickets of the shows are booked or held, keyed by show ID.
+// Shows without booked tickets are missing. Seats of refunded tickets are not booked anymore.
+// Everything which needs to know how many seats of a show are taken must use it, so their counts agree.
+func BookedTickets(ctx context.Context, q sqlx.QueryerContext, showIDs []string) (map[string]int, error) {
+	var rows []struct {
+		ShowID        string `db:"show_id"`
+		BookedTickets int    `db:"booked_tickets"`
+	}
+	err := sqlx.SelectContext(ctx, q, &rows, `
+		SELECT show_id, SUM(tickets) AS booked_tickets
+		FROM (
+			SELECT show_id, number_of_tickets AS tickets
+			FROM bookings
+			WHERE show_id = ANY($1) AND canceled_at IS NULL
+
+			UNION ALL
+
+			-- tickets of canceled bookings are already freed with the booking
+			SELECT b.show_id, -1 AS tickets
+			FROM ticket_refunds r
+			JOIN tickets t ON t.ticket_id = r.ticket_id
+			JOIN bookings b ON b.booking_id = t.booking_id
+			WHERE b.show_id = ANY($1) AND b.canceled_at IS NULL AND r.status = $2
+
+			UNION ALL
+
+			SELECT show_id, number_of_tickets AS tickets
+			FROM seat_holds
+			WHERE show_id = ANY($1) AND confirmed_at IS NULL AND expired_at IS NULL AND expires_at > NOW()
+		) AS taken
+		GROUP BY show_id
+		`, pq.Array(showIDs), entity.TicketRefundStatusCompleted)
+	if err != nil {
+		return nil, fmt.Errorf("could not get booked tickets count: %w", err)
+	}
+
+	bookedTickets := make(map[string]int, len(rows))
+	for _, row := range rows {
+		bookedTickets[row.ShowID] = row.BookedTickets
+	}
+
+	return bookedTickets, nil
+}
@@ -0,0 +1,57 @@
+package bookings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"tickets/db/promotions"
+	"tickets/entity"
+)
+
+// applyPromoCode redeems the booking's promo code and stores its discount with the booking.
+// It must be called after the booking is quoted, as fixed discounts can be applied only to prices in the same currency.
+func (r *PostgresRepository) applyPromoCode(ctx context.Context, tx *sqlx.Tx, booking *entity.Booking) error {
+	if booking.PromoCode == "" {
+		return nil
+	}
+
+	discount, err := promotions.Redeem(ctx, tx, booking.PromoCode, booking.ShowID)
+	if err != nil {
+		return err
+	}
+
+	if discount.Type == entity.DiscountTypeFixed {
+		// tickets of shows without a quote are priced outside of this service, in an unknown currency
+		if len(booking.Quote) == 0 || booking.Quote[0].Price.Currency != discount.Amount.Currency {
+			return fmt.Errorf(
+				"%w: %s can be used only for shows priced in %s",
+				entity.ErrInvalidPromoCode, discount.PromoCode, discount.Amount.Currency,
+			)
+		}
+	}
+
+	payload, err := json.Marshal(discount)
+	if err != nil {
+		return fmt.Errorf("could not marshal discount: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE bookings SET discount = $2 WHERE booking_id = $1`, booking.BookingID, payload)
+	if err != nil {
+		return fmt.Errorf("could not store discount: %w", err)
+	}
+
+	booking.Discount = &discount
+	return nil
+}
+
+// releasePromoCode gives back the use of the promo code of the canceled booking.
+func (r *PostgresRepository) releasePromoCode(ctx context.Context, tx *sqlx.Tx, booking entity.Booking) error {
+	if booking.Discount == nil {
+		return nil
+	}
+
+	return promotions.Release(ctx, tx, booking.Discount.PromoCode)
+}
@@ -0,0 +1,164 @@
+package bookings
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tickets/db"
+	"tickets/db/promotions"
+	"tickets/db/shows"
+	"tickets/entity"
+)
+
+func TestPostgresRepository_Promotions(t *testing.T) {
+	ctx := context.Background()
+	container, url := db.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+
+	dbConn := db.GetDb(t)
+	repo := NewPostgresRepository(dbConn)
+	repoShows := shows.NewPostgresRepository(dbConn)
+	repoPromotions := promotions.NewPostgresRepository(dbConn)
+
+	show := entity.Show{
+		ShowID:          uuid.NewString(),
+		NumberOfTickets: 100,
+		PriceCategories: []entity.PriceCategory{
+			{Name: "standard", Price: entity.Money{Amount: decimal.RequireFromString("50.00"), Currency: "EUR"}},
+		},
+	}
+	err := repoShows.Store(ctx, show)
+	require.NoError(t, err)
+
+	newBooking := func(promoCode string) entity.Booking {
+		return entity.Booking{
+			BookingID:       uuid.NewString(),
+			ShowID:          show.ShowID,
+			NumberOfTickets: 1,
+			CustomerEmail:   "test@test.io",
+			PromoCode:       promoCode,
+		}
+	}
+
+	t.Run("usage_limit", func(t *testing.T) {
+		percentage := decimal.NewFromInt(10)
+		usageLimit := 3
+		promotion := entity.Promotion{
+			Code: "LIMITED-" + uuid.NewString(),
+			Discount: entity.Discount{
+				Type:       entity.DiscountTypePercentage,
+				Percentage: &percentage,
+			},
+			UsageLimit: &usageLimit,
+		}
+		err := repoPromotions.Store(ctx, promotion)
+		require.NoError(t, err)
+
+		err = repoPromotions.Store(ctx, promotion)
+		assert.ErrorIs(t, err, entity.ErrConflict)
+
+		var (
+			wg         sync.WaitGroup
+			succeeded  atomic.Int64
+			bookingIDs = make(chan string, 10)
+			start      = make(chan struct{})
+		)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+
+				booking := newBooking(promotion.Code)
+				err := repo.Store(ctx, booking)
+				if errors.Is(err, entity.ErrInvalidPromoCode) {
+					return
+				}
+				if assert.NoError(t, err) {
+					succeeded.Add(1)
+					bookingIDs <- booking.BookingID
+				}
+			}()
+		}
+
+		close(start)
+		wg.Wait()
+		close(bookingIDs)
+
+		assert.EqualValues(t, usageLimit, succeeded.Load())
+
+		storedPromotion, err := repoPromotions.Get(ctx, promotion.Code)
+		require.NoError(t, err)
+		assert.Equal(t, usageLimit, storedPromotion.UsedCount)
+
+		bookingID := <-bookingIDs
+		booking, err := repo.Get(ctx, bookingID)
+		require.NoError(t, err)
+		require.NotNil(t, booking.Discount)
+		assert.Equal(t, promotion.Code, booking.Discount.PromoCode)
+
+		price, err := booking.DiscountedTicketPrice(show.PriceCategories[0].Price)
+		require.NoError(t, err)
+		assert.True(t, price.Equal(entity.Money{Amount: decimal.RequireFromString("45"), Currency: "EUR"}), price.String())
+
+		// canceled bookings give back their use of the code
+		err = repo.Cancel(ctx, bookingID)
+		require.NoError(t, err)
+
+		storedPromotion, err = repoPromotions.Get(ctx, promotion.Code)
+		require.NoError(t, err)
+		assert.Equal(t, usageLimit-1, storedPromotion.UsedCount)
+
+		err = repo.Store(ctx, newBooking(promotion.Code))
+		assert.NoError(t, err)
+	})
+
+	t.Run("not_applicable", func(t *testing.T) {
+		otherShowID := uuid.NewString()
+		err := repoShows.Store(ctx, entity.Show{ShowID: otherShowID, NumberOfTickets: 10})
+		require.NoError(t, err)
+
+		percentage := decimal.NewFromInt(20)
+		validUntil := time.Now().Add(-time.Hour)
+		usd := entity.Money{Amount: decimal.NewFromInt(5), Currency: "USD"}
+
+		for name, promotion := range map[string]entity.Promotion{
+			"other_show": {
+				Discount: entity.Discount{Type: entity.DiscountTypePercentage, Percentage: &percentage},
+				ShowID:   &otherShowID,
+			},
+			"expired": {
+				Discount:   entity.Discount{Type: entity.DiscountTypePercentage, Percentage: &percentage},
+				ValidUntil: &validUntil,
+			},
+			"other_currency": {
+				Discount: entity.Discount{Type: entity.DiscountTypeFixed, Amount: &usd},
+			},
+		} {
+			promotion.Code = name + "-" + uuid.NewString()
+			err := repoPromotions.Store(ctx, promotion)
+			require.NoError(t, err)
+
+			err = repo.Store(ctx, newBooking(promotion.Code))
+			assert.ErrorIs(t, err, entity.ErrInvalidPromoCode, name)
+
+			storedPromotion, err := repoPromotions.Get(ctx, promotion.Code)
+			require.NoError(t, err)
+			assert.Equal(t, 0, storedPromotion.UsedCount, name)
+		}
+
+		err = repo.Store(ctx, newBooking("UNKNOWN"))
+		assert.ErrorIs(t, err, entity.ErrInvalidPromoCode)
+	})
+}
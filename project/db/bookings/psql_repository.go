@@ -3,13 +3,16 @@ package bookings
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 
+	"tickets/db"
 	"tickets/entity"
-	"tickets/pubsub/bus"
 	"tickets/pubsub/outbox"
 )
 
@@ -22,82 +25,259 @@ func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
 }
 
 // Store stores booking in database and publishes event to event bus.
-// It uses transaction to ensure that booking is stored only if there are available tickets.
-func (r *PostgresRepository) Store(ctx context.Context, booking entity.Booking, showTicketsCount int) error {
-	tx, err := r.db.BeginTxx(ctx, nil)
+// Booking is stored only if there are available tickets, concurrent bookings of the show are serialized.
+// Storing an already stored booking does nothing, so a redelivered command booking it is handled as a success.
+func (r *PostgresRepository) Store(ctx context.Context, booking entity.Booking) error {
+	return db.UpdateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			// a redelivered booking is stored even when its show was canceled since, so it's checked before the lock
+			var exists bool
+			err := tx.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM bookings WHERE booking_id = $1)`, booking.BookingID)
+			if err != nil {
+				return fmt.Errorf("could not check if booking exists: %w", err)
+			}
+			if exists {
+				// BookingMade_v1 was published in the transaction which stored it
+				return nil
+			}
+
+			availableTickets, err := r.lockAvailableTickets(ctx, tx, booking.ShowID)
+			if err != nil {
+				return err
+			}
+
+			if availableTickets < booking.NumberOfTickets {
+				return entity.ErrNoAvailableTickets
+			}
+
+			_, err = tx.NamedExecContext(ctx, `
+				INSERT INTO 
+				    bookings (booking_id, show_id, number_of_tickets, customer_email, price_category) 
+				VALUES (:booking_id, :show_id, :number_of_tickets, :customer_email, :price_category)
+				`, booking)
+			if err != nil {
+				return fmt.Errorf("could not add booking: %w", err)
+			}
+
+			seats, err := r.allocateSeats(ctx, tx, &booking)
+			if err != nil {
+				return err
+			}
+
+			err = r.quoteBooking(ctx, tx, &booking, seats)
+			if err != nil {
+				return err
+			}
+
+			err = r.applyPromoCode(ctx, tx, &booking)
+			if err != nil {
+				return err
+			}
+
+			eventBus, err := outbox.NewEventBusForTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			err = eventBus.Publish(ctx, entity.BookingMade_v1{
+				Header:          entity.NewEventHeader(),
+				BookingID:       booking.BookingID,
+				NumberOfTickets: booking.NumberOfTickets,
+				CustomerEmail:   booking.CustomerEmail,
+				ShowID:          booking.ShowID,
+				Seats:           booking.Seats,
+				Quote:           booking.Quote,
+				Discount:        booking.Discount,
+			})
+			if err != nil {
+				return fmt.Errorf("could not publish event: %w", err)
+			}
+
+			return nil
+		},
+	)
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, bookingID string) (entity.Booking, error) {
+	return r.getBooking(ctx, r.db, bookingID, false)
+}
+
+type bookingRow struct {
+	entity.Booking
+	QuotePayload    []byte `db:"quote"`
+	DiscountPayload []byte `db:"discount"`
+}
+
+func (r *PostgresRepository) getBooking(ctx context.Context, q sqlx.QueryerContext, bookingID string, forUpdate bool) (entity.Booking, error) {
+	if _, err := uuid.Parse(bookingID); err != nil {
+		// booking IDs of tickets booked outside of the service are not UUIDs
+		return entity.Booking{}, entity.ErrNotFound
+	}
+
+	query := `
+		SELECT booking_id, show_id, number_of_tickets, customer_email, canceled_at, price_category, quote, discount
+		FROM bookings
+		WHERE booking_id = $1
+	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var row bookingRow
+	err := sqlx.GetContext(ctx, q, &row, query, bookingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Booking{}, entity.ErrNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("could not begin transaction: %w", err)
+		return entity.Booking{}, fmt.Errorf("could not get booking: %w", err)
 	}
 
-	defer func() {
-		if err != nil {
-			rollbackErr := tx.Rollback()
-			err = errors.Join(err, rollbackErr)
-			return
+	booking := row.Booking
+	if row.QuotePayload != nil {
+		if err := json.Unmarshal(row.QuotePayload, &booking.Quote); err != nil {
+			return entity.Booking{}, fmt.Errorf("could not unmarshal quote: %w", err)
+		}
+	}
+	if row.DiscountPayload != nil {
+		if err := json.Unmarshal(row.DiscountPayload, &booking.Discount); err != nil {
+			return entity.Booking{}, fmt.Errorf("could not unmarshal discount: %w", err)
 		}
-		err = tx.Commit()
-	}()
+		booking.PromoCode = booking.Discount.PromoCode
+	}
 
-	availableTickets, err := r.getAvailableTickets(ctx, tx, booking.ShowID, showTicketsCount)
+	booking.Seats, err = r.bookingSeats(ctx, q, bookingID)
 	if err != nil {
-		return fmt.Errorf("could not get available tickets: %w", err)
+		return entity.Booking{}, err
 	}
 
-	if availableTickets < booking.NumberOfTickets {
-		return entity.ErrNoAvailableTickets
+	return booking, nil
+}
+
+// Cancel marks the booking as canceled, so its seats are available again, and publishes BookingCanceled_v1.
+// Canceling already canceled booking is a no-op.
+func (r *PostgresRepository) Cancel(ctx context.Context, bookingID string) error {
+	return db.UpdateInTx(
+		ctx,
+		r.db,
+		// the booking is locked, and its promotion is updated by concurrent bookings,
+		// so the promotion's use must be released from its latest version
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			booking, err := r.getBooking(ctx, tx, bookingID, true)
+			if err != nil {
+				return err
+			}
+
+			if booking.CanceledAt != nil {
+				return nil
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				UPDATE bookings SET canceled_at = NOW() WHERE booking_id = $1
+			`, bookingID)
+			if err != nil {
+				return fmt.Errorf("could not cancel booking: %w", err)
+			}
+
+			err = r.releaseBookingSeats(ctx, tx, bookingID)
+			if err != nil {
+				return err
+			}
+
+			err = r.releasePromoCode(ctx, tx, booking)
+			if err != nil {
+				return err
+			}
+
+			eventBus, err := outbox.NewEventBusForTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			err = eventBus.Publish(ctx, entity.BookingCanceled_v1{
+				Header:          entity.NewEventHeader(),
+				BookingID:       booking.BookingID,
+				NumberOfTickets: booking.NumberOfTickets,
+				CustomerEmail:   booking.CustomerEmail,
+				ShowID:          booking.ShowID,
+			})
+			if err != nil {
+				return fmt.Errorf("could not publish event: %w", err)
+			}
+
+			return nil
+		},
+	)
+}
+
+// AvailableTickets returns how many tickets of each of the shows can still be booked, keyed by show ID.
+// Seats of active holds are not available, seats of refunded tickets are available again.
+// Tickets of all shows are counted in one query, so listing shows doesn't query each of them.
+func (r *PostgresRepository) AvailableTickets(ctx context.Context, shows ...entity.Show) (map[string]int, error) {
+	if len(shows) == 0 {
+		return map[string]int{}, nil
 	}
 
-	_, err = tx.NamedExecContext(ctx, `
-		INSERT INTO 
-		    bookings (booking_id, show_id, number_of_tickets, customer_email) 
-		VALUES (:booking_id, :show_id, :number_of_tickets, :customer_email)
-		`, booking)
-	if err != nil {
-		return fmt.Errorf("could not add booking: %w", err)
+	showIDs := make([]string, 0, len(shows))
+	for _, show := range shows {
+		showIDs = append(showIDs, show.ShowID)
 	}
 
-	outboxPublisher, err := outbox.NewPublisherForDb(ctx, tx)
+	bookedTickets, err := db.BookedTickets(ctx, r.db, showIDs)
 	if err != nil {
-		return fmt.Errorf("could not create event bus: %w", err)
+		return nil, err
 	}
 
-	eventBus, err := bus.NewEventBus(outboxPublisher)
+	availableTickets := make(map[string]int, len(shows))
+	for _, show := range shows {
+		availableTickets[show.ShowID] = show.NumberOfTickets - bookedTickets[show.ShowID]
+	}
+
+	return availableTickets, nil
+}
+
+// lockAvailableTickets locks the show's inventory until the end of the transaction and returns
+// how many tickets can be booked. All changes which take seats of the show must call it first,
+// so they can't oversell the show when running concurrently.
+func (r *PostgresRepository) lockAvailableTickets(ctx context.Context, tx *sqlx.Tx, showID string) (int, error) {
+	showTicketsCount, err := r.lockShowInventory(ctx, tx, showID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = eventBus.Publish(ctx, entity.BookingMade_v1{
-		Header:          entity.NewEventHeader(),
-		BookingID:       booking.BookingID,
-		NumberOfTickets: booking.NumberOfTickets,
-		CustomerEmail:   booking.CustomerEmail,
-		ShowID:          booking.ShowID,
-	})
+	bookedTickets, err := db.BookedTickets(ctx, tx, []string{showID})
 	if err != nil {
-		return fmt.Errorf("could not publish event: %w", err)
+		return 0, fmt.Errorf("could not get available tickets: %w", err)
 	}
 
-	return nil
+	return showTicketsCount - bookedTickets[showID], nil
 }
 
-func (r *PostgresRepository) getAvailableTickets(ctx context.Context, tx *sqlx.Tx, showID string, showTicketsCount int) (int, error) {
-	var bookedTicketsCount int
-	err := tx.GetContext(ctx, &bookedTicketsCount, `
-		SELECT 
-		    COALESCE(SUM(number_of_tickets), 0) 
-		FROM 
-		    bookings 
-		WHERE 
-		    show_id = $1
-		GROUP BY 
-		    show_id
-		`, showID)
+// lockShowInventory locks the show's row until the end of the transaction and returns its number of tickets.
+// Seats of canceled shows can't be taken, the show is canceled under the same lock.
+func (r *PostgresRepository) lockShowInventory(ctx context.Context, tx *sqlx.Tx, showID string) (int, error) {
+	var show struct {
+		NumberOfTickets int        `db:"number_of_tickets"`
+		CanceledAt      *time.Time `db:"canceled_at"`
+	}
+	err := tx.GetContext(ctx, &show, `
+		SELECT number_of_tickets, canceled_at
+		FROM shows
+		WHERE show_id = $1
+		FOR UPDATE
+	`, showID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, entity.ErrNotFound
+	}
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return showTicketsCount, nil
-		}
-		return 0, fmt.Errorf("could not get booked tickets count: %w", err)
+		return 0, fmt.Errorf("could not lock show inventory: %w", err)
+	}
+	if show.CanceledAt != nil {
+		return 0, entity.ErrShowCanceled
 	}
 
-	return showTicketsCount - bookedTicketsCount, nil
+	return show.NumberOfTickets, nil
 }
@@ -2,13 +2,20 @@ package bookings
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"tickets/db"
 	"tickets/db/shows"
+	"tickets/db/tickets"
 	"tickets/entity"
 )
 
@@ -37,8 +44,160 @@ func TestPostgresRepository_Store(t *testing.T) {
 		BookingID:       uuid.NewString(),
 	}
 
-	err = repo.Store(ctx, booking, show.NumberOfTickets)
+	err = repo.Store(ctx, booking)
 	errNoAvailableTickets := entity.ErrNoAvailableTickets
 	assert.ErrorAs(t, err, &errNoAvailableTickets)
 
+	_, err = repo.Get(ctx, booking.BookingID)
+	assert.ErrorIs(t, err, entity.ErrNotFound)
+
+	booking.NumberOfTickets = 1
+	err = repo.Store(ctx, booking)
+	assert.NoError(t, err)
+
+	storedBooking, err := repo.Get(ctx, booking.BookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, booking, storedBooking)
+
+	// redelivered command stores the same booking again, even when the show is sold out
+	err = repo.Store(ctx, booking)
+	assert.NoError(t, err)
+
+	storedBooking, err = repo.Get(ctx, booking.BookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, booking, storedBooking)
+}
+
+func TestPostgresRepository_Store_concurrently(t *testing.T) {
+	ctx := context.Background()
+	container, url := db.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+
+	dbConn := db.GetDb(t)
+	repo := NewPostgresRepository(dbConn)
+	repoShows := shows.NewPostgresRepository(dbConn)
+
+	show := entity.Show{
+		ShowID:          uuid.NewString(),
+		NumberOfTickets: 10,
+	}
+	err := repoShows.Store(ctx, show)
+	require.NoError(t, err)
+
+	const (
+		workers             = 50
+		ticketsPerBooking   = 2
+		expectedBookedSeats = 10
+	)
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+		start     = make(chan struct{})
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			var err error
+			if i%2 == 0 {
+				err = repo.Store(ctx, entity.Booking{
+					BookingID:       uuid.NewString(),
+					ShowID:          show.ShowID,
+					NumberOfTickets: ticketsPerBooking,
+					CustomerEmail:   "test@test.io",
+				})
+			} else {
+				err = repo.Hold(ctx, entity.SeatHold{
+					HoldID:          uuid.NewString(),
+					ShowID:          show.ShowID,
+					NumberOfTickets: ticketsPerBooking,
+					CustomerEmail:   "test@test.io",
+					ExpiresAt:       time.Now().Add(time.Minute),
+				})
+			}
+			if errors.Is(err, entity.ErrNoAvailableTickets) {
+				return
+			}
+			if assert.NoError(t, err) {
+				succeeded.Add(1)
+			}
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	assert.EqualValues(t, expectedBookedSeats/ticketsPerBooking, succeeded.Load())
+
+	availableTickets, err := repo.AvailableTickets(ctx, show)
+	require.NoError(t, err)
+	assert.Equal(t, 0, availableTickets[show.ShowID], "show must not be oversold")
+}
+
+func TestPostgresRepository_refunded_tickets_free_show_capacity(t *testing.T) {
+	ctx := context.Background()
+	container, url := db.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+
+	dbConn := db.GetDb(t)
+	repo := NewPostgresRepository(dbConn)
+	repoShows := shows.NewPostgresRepository(dbConn)
+	repoTickets := tickets.NewPostgresRepository(dbConn)
+
+	show := entity.Show{
+		ShowID:          uuid.NewString(),
+		NumberOfTickets: 2,
+	}
+	err := repoShows.Store(ctx, show)
+	require.NoError(t, err)
+
+	booking := entity.Booking{
+		BookingID:       uuid.NewString(),
+		ShowID:          show.ShowID,
+		NumberOfTickets: 2,
+		CustomerEmail:   "test@test.io",
+	}
+	err = repo.Store(ctx, booking)
+	require.NoError(t, err)
+
+	ticket := entity.NewTicket(
+		uuid.NewString(),
+		booking.BookingID,
+		entity.Money{Amount: decimal.RequireFromString("30.00"), Currency: "EUR"},
+		booking.CustomerEmail,
+	)
+	err = repoTickets.Store(ctx, ticket)
+	require.NoError(t, err)
+
+	_, err = dbConn.ExecContext(ctx, `
+		INSERT INTO ticket_refunds (ticket_id, status, completed_at) VALUES ($1, $2, NOW())
+	`, ticket.TicketID, entity.TicketRefundStatusCompleted)
+	require.NoError(t, err)
+
+	availableTickets, err := repo.AvailableTickets(ctx, show)
+	require.NoError(t, err)
+	assert.Equal(t, 1, availableTickets[show.ShowID], "seat of the refunded ticket should be available")
+
+	// the capacity can be lowered to the seats which are still booked
+	_, err = repoShows.Update(ctx, show.ShowID, entity.ShowUpdate{NumberOfTickets: intPtr(0)})
+	assert.ErrorIs(t, err, entity.ErrCapacityBelowBookedSeats)
+
+	updatedShow, err := repoShows.Update(ctx, show.ShowID, entity.ShowUpdate{NumberOfTickets: intPtr(1)})
+	require.NoError(t, err)
+
+	availableTickets, err = repo.AvailableTickets(ctx, updatedShow)
+	require.NoError(t, err)
+	assert.Equal(t, 0, availableTickets[show.ShowID])
+}
+
+func intPtr(i int) *int {
+	return &i
 }
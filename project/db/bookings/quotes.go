@@ -0,0 +1,61 @@
+package bookings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"tickets/db"
+	"tickets/entity"
+)
+
+type priceCategoryRow struct {
+	Name string `db:"name"`
+	db.PriceColumns
+}
+
+// quoteBooking prices tickets of the booking with the show's current price categories
+// and stores the quote, so later changes of prices don't affect the booking.
+func (r *PostgresRepository) quoteBooking(ctx context.Context, tx *sqlx.Tx, booking *entity.Booking, seats []entity.ShowSeat) error {
+	var rows []priceCategoryRow
+	err := tx.SelectContext(ctx, &rows, `
+		SELECT name, price_amount, price_currency
+		FROM show_price_categories
+		WHERE show_id = $1
+		ORDER BY position
+	`, booking.ShowID)
+	if err != nil {
+		return fmt.Errorf("could not get price categories: %w", err)
+	}
+
+	show := entity.Show{ShowID: booking.ShowID}
+	for _, row := range rows {
+		show.PriceCategories = append(show.PriceCategories, entity.PriceCategory{
+			Name:  row.Name,
+			Price: row.Money(),
+		})
+	}
+
+	quote, err := show.QuoteTickets(booking.PriceCategory, booking.NumberOfTickets, seats)
+	if err != nil {
+		return err
+	}
+	if quote == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(quote)
+	if err != nil {
+		return fmt.Errorf("could not marshal quote: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE bookings SET quote = $2 WHERE booking_id = $1`, booking.BookingID, payload)
+	if err != nil {
+		return fmt.Errorf("could not store quote: %w", err)
+	}
+
+	booking.Quote = quote
+	return nil
+}
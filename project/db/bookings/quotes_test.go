@@ -0,0 +1,159 @@
+package bookings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tickets/db"
+	"tickets/db/shows"
+	"tickets/entity"
+)
+
+func TestPostgresRepository_Quotes(t *testing.T) {
+	ctx := context.Background()
+	container, url := db.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+
+	repo := NewPostgresRepository(db.GetDb(t))
+	repoShows := shows.NewPostgresRepository(db.GetDb(t))
+
+	standard := entity.PriceCategory{Name: "standard", Price: entity.Money{Amount: decimal.RequireFromString("50.00"), Currency: "EUR"}}
+	premium := entity.PriceCategory{Name: "premium", Price: entity.Money{Amount: decimal.RequireFromString("120.00"), Currency: "EUR"}}
+
+	newShow := func(numberOfTickets int) entity.Show {
+		show := entity.Show{
+			ShowID:          uuid.NewString(),
+			NumberOfTickets: numberOfTickets,
+			PriceCategories: []entity.PriceCategory{standard, premium},
+		}
+		err := repoShows.Store(ctx, show)
+		require.NoError(t, err)
+
+		storedShow, err := repoShows.Get(ctx, show.ShowID)
+		require.NoError(t, err)
+		require.Len(t, storedShow.PriceCategories, len(show.PriceCategories))
+		for i, category := range show.PriceCategories {
+			assert.Equal(t, category.Name, storedShow.PriceCategories[i].Name)
+			assert.True(t, category.Price.Equal(storedShow.PriceCategories[i].Price))
+		}
+
+		return show
+	}
+
+	t.Run("price_category", func(t *testing.T) {
+		show := newShow(10)
+
+		booking := entity.Booking{
+			BookingID:       uuid.NewString(),
+			ShowID:          show.ShowID,
+			NumberOfTickets: 2,
+			CustomerEmail:   "test@test.io",
+			PriceCategory:   premium.Name,
+		}
+		err := repo.Store(ctx, booking)
+		require.NoError(t, err)
+
+		// prices changed after booking don't change the quote
+		newPrices := []entity.PriceCategory{
+			{Name: premium.Name, Price: entity.Money{Amount: decimal.RequireFromString("150.00"), Currency: "EUR"}},
+		}
+		_, err = repoShows.Update(ctx, show.ShowID, entity.ShowUpdate{PriceCategories: &newPrices})
+		require.NoError(t, err)
+
+		storedBooking, err := repo.Get(ctx, booking.BookingID)
+		require.NoError(t, err)
+		assertQuote(t, []entity.TicketQuote{
+			{Category: premium.Name, Price: premium.Price},
+			{Category: premium.Name, Price: premium.Price},
+		}, storedBooking.Quote)
+
+		price, ok := storedBooking.QuotedTicketPrice(nil)
+		assert.True(t, ok)
+		assert.True(t, premium.Price.Equal(price))
+
+		err = repo.Store(ctx, entity.Booking{
+			BookingID:       uuid.NewString(),
+			ShowID:          show.ShowID,
+			NumberOfTickets: 1,
+			CustomerEmail:   "test@test.io",
+			PriceCategory:   "unknown",
+		})
+		assert.ErrorIs(t, err, entity.ErrUnknownPriceCategory)
+	})
+
+	t.Run("seat_categories", func(t *testing.T) {
+		show := newShow(0)
+
+		seatMap := entity.SeatMap{
+			Sections: []entity.SeatMapSection{
+				{Name: "A", Category: premium.Name, Rows: []entity.SeatMapRow{{Row: "1", Seats: 1}}},
+				{Name: "B", Rows: []entity.SeatMapRow{{Row: "1", Seats: 1}}},
+			},
+		}
+		seats, err := seatMap.ShowSeats()
+		require.NoError(t, err)
+
+		err = repo.SetSeatMap(ctx, show.ShowID, seats)
+		require.NoError(t, err)
+
+		booking := entity.Booking{
+			BookingID:       uuid.NewString(),
+			ShowID:          show.ShowID,
+			NumberOfTickets: 2,
+			CustomerEmail:   "test@test.io",
+		}
+		err = repo.Store(ctx, booking)
+		require.NoError(t, err)
+
+		storedBooking, err := repo.Get(ctx, booking.BookingID)
+		require.NoError(t, err)
+
+		premiumSeat := entity.Seat{Section: "A", Row: "1", Number: 1}
+		standardSeat := entity.Seat{Section: "B", Row: "1", Number: 1}
+		assertQuote(t, []entity.TicketQuote{
+			{Category: premium.Name, Seat: &premiumSeat, Price: premium.Price},
+			{Category: standard.Name, Seat: &standardSeat, Price: standard.Price},
+		}, storedBooking.Quote)
+
+		price, ok := storedBooking.QuotedTicketPrice(&standardSeat)
+		assert.True(t, ok)
+		assert.True(t, standard.Price.Equal(price))
+	})
+}
+
+// assertQuote compares quotes in any order. Prices are compared numerically,
+// as amounts loaded from the database have a different scale.
+func assertQuote(t *testing.T, expected []entity.TicketQuote, actual []entity.TicketQuote) {
+	t.Helper()
+
+	require.Len(t, actual, len(expected))
+
+	matched := make([]bool, len(actual))
+	for _, expectedQuote := range expected {
+		found := false
+		for i, actualQuote := range actual {
+			if matched[i] || actualQuote.Category != expectedQuote.Category || !actualQuote.Price.Equal(expectedQuote.Price) {
+				continue
+			}
+			if (actualQuote.Seat == nil) != (expectedQuote.Seat == nil) {
+				continue
+			}
+			if actualQuote.Seat != nil && *actualQuote.Seat != *expectedQuote.Seat {
+				continue
+			}
+
+			matched[i] = true
+			found = true
+			break
+		}
+
+		assert.True(t, found, "quote %+v not found in %+v", expectedQuote, actual)
+	}
+}
@@ -0,0 +1,253 @@
+package bookings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"tickets/db"
+	"tickets/entity"
+	"tickets/pubsub/outbox"
+)
+
+const seatHoldColumns = "hold_id, show_id, number_of_tickets, customer_email, expires_at, booking_id, confirmed_at, expired_at"
+
+// Hold reserves seats until the hold expires and publishes SeatsHeld_v1.
+func (r *PostgresRepository) Hold(ctx context.Context, hold entity.SeatHold) error {
+	return db.UpdateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			return r.hold(ctx, tx, hold)
+		},
+	)
+}
+
+func (r *PostgresRepository) hold(ctx context.Context, tx *sqlx.Tx, hold entity.SeatHold) error {
+	availableTickets, err := r.lockAvailableTickets(ctx, tx, hold.ShowID)
+	if err != nil {
+		return err
+	}
+
+	if availableTickets < hold.NumberOfTickets {
+		return entity.ErrNoAvailableTickets
+	}
+
+	_, err = tx.NamedExecContext(ctx, `
+		INSERT INTO seat_holds (hold_id, show_id, number_of_tickets, customer_email, expires_at)
+		VALUES (:hold_id, :show_id, :number_of_tickets, :customer_email, :expires_at)
+	`, hold)
+	if err != nil {
+		return fmt.Errorf("could not add seat hold: %w", err)
+	}
+
+	eventBus, err := outbox.NewEventBusForTx(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	err = eventBus.Publish(ctx, entity.SeatsHeld_v1{
+		Header:          entity.NewEventHeader(),
+		HoldID:          hold.HoldID,
+		ShowID:          hold.ShowID,
+		NumberOfTickets: hold.NumberOfTickets,
+		CustomerEmail:   hold.CustomerEmail,
+		ExpiresAt:       hold.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("could not publish event: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmHold turns the hold into a booking and publishes BookingMade_v1.
+// Confirming an already confirmed hold returns its booking.
+func (r *PostgresRepository) ConfirmHold(ctx context.Context, holdID string, bookingID string) (entity.Booking, error) {
+	var booking entity.Booking
+
+	err := db.UpdateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			hold, err := r.getHold(ctx, tx, holdID, false)
+			if err != nil {
+				return err
+			}
+
+			if hold.BookingID != nil {
+				booking, err = r.getBooking(ctx, tx, *hold.BookingID, false)
+				return err
+			}
+			// seats of the hold are free as soon as it expires or its show is canceled, so the hold is checked
+			// while the show's inventory is locked, the same way as by bookings of these seats;
+			// the show is locked before the hold, in the same order as when the show is canceled
+			if _, err := r.lockShowInventory(ctx, tx, hold.ShowID); err != nil {
+				return err
+			}
+
+			hold, err = r.getHold(ctx, tx, holdID, true)
+			if err != nil {
+				return err
+			}
+			if hold.BookingID != nil {
+				// confirmed while waiting for the lock
+				booking, err = r.getBooking(ctx, tx, *hold.BookingID, false)
+				return err
+			}
+
+			var expired bool
+			err = tx.GetContext(ctx, &expired, `
+				SELECT expired_at IS NOT NULL OR expires_at <= NOW()
+				FROM seat_holds
+				WHERE hold_id = $1
+			`, holdID)
+			if err != nil {
+				return fmt.Errorf("could not check seat hold expiration: %w", err)
+			}
+			if expired {
+				return entity.ErrSeatHoldExpired
+			}
+
+			booking = entity.Booking{
+				BookingID:       bookingID,
+				ShowID:          hold.ShowID,
+				NumberOfTickets: hold.NumberOfTickets,
+				CustomerEmail:   hold.CustomerEmail,
+			}
+
+			// seats are already reserved by the hold, so availability is not checked again
+			_, err = tx.NamedExecContext(ctx, `
+				INSERT INTO bookings (booking_id, show_id, number_of_tickets, customer_email)
+				VALUES (:booking_id, :show_id, :number_of_tickets, :customer_email)
+			`, booking)
+			if err != nil {
+				return fmt.Errorf("could not add booking: %w", err)
+			}
+
+			seats, err := r.allocateSeats(ctx, tx, &booking)
+			if err != nil {
+				return err
+			}
+
+			// holds have no price category selected, so tickets are priced by the seat's or the default category
+			err = r.quoteBooking(ctx, tx, &booking, seats)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				UPDATE seat_holds SET confirmed_at = NOW(), booking_id = $2 WHERE hold_id = $1
+			`, holdID, bookingID)
+			if err != nil {
+				return fmt.Errorf("could not confirm seat hold: %w", err)
+			}
+
+			eventBus, err := outbox.NewEventBusForTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			err = eventBus.Publish(ctx, entity.BookingMade_v1{
+				Header:          entity.NewEventHeader(),
+				BookingID:       booking.BookingID,
+				NumberOfTickets: booking.NumberOfTickets,
+				CustomerEmail:   booking.CustomerEmail,
+				ShowID:          booking.ShowID,
+				Seats:           booking.Seats,
+				Quote:           booking.Quote,
+			})
+			if err != nil {
+				return fmt.Errorf("could not publish event: %w", err)
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return entity.Booking{}, err
+	}
+
+	return booking, nil
+}
+
+func (r *PostgresRepository) getHold(ctx context.Context, tx *sqlx.Tx, holdID string, forUpdate bool) (entity.SeatHold, error) {
+	query := `
+		SELECT ` + seatHoldColumns + `
+		FROM seat_holds
+		WHERE hold_id = $1
+	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var hold entity.SeatHold
+	err := tx.GetContext(ctx, &hold, query, holdID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.SeatHold{}, entity.ErrNotFound
+	}
+	if err != nil {
+		return entity.SeatHold{}, fmt.Errorf("could not get seat hold: %w", err)
+	}
+
+	return hold, nil
+}
+
+// ExpireHolds releases seats of holds which were not confirmed in time and publishes SeatHoldExpired_v1 for each.
+// It's safe to run concurrently from many service instances. It returns the number of expired holds.
+func (r *PostgresRepository) ExpireHolds(ctx context.Context) (int, error) {
+	var expired []entity.SeatHold
+
+	err := db.UpdateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			err := tx.SelectContext(ctx, &expired, `
+				UPDATE seat_holds SET expired_at = NOW()
+				WHERE hold_id IN (
+					SELECT hold_id
+					FROM seat_holds
+					WHERE confirmed_at IS NULL AND expired_at IS NULL AND expires_at <= NOW()
+					FOR UPDATE SKIP LOCKED
+				)
+				RETURNING `+seatHoldColumns)
+			if err != nil {
+				return fmt.Errorf("could not expire seat holds: %w", err)
+			}
+
+			if len(expired) == 0 {
+				return nil
+			}
+
+			eventBus, err := outbox.NewEventBusForTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			for _, hold := range expired {
+				err = eventBus.Publish(ctx, entity.SeatHoldExpired_v1{
+					Header:          entity.NewEventHeader(),
+					HoldID:          hold.HoldID,
+					ShowID:          hold.ShowID,
+					NumberOfTickets: hold.NumberOfTickets,
+				})
+				if err != nil {
+					return fmt.Errorf("could not publish event: %w", err)
+				}
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(expired), nil
+}
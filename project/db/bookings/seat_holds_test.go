@@ -0,0 +1,135 @@
+package bookings
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tickets/db"
+	"tickets/db/shows"
+	"tickets/entity"
+)
+
+func TestPostgresRepository_SeatHolds(t *testing.T) {
+	ctx := context.Background()
+	container, url := db.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+
+	repo := NewPostgresRepository(db.GetDb(t))
+	repoShows := shows.NewPostgresRepository(db.GetDb(t))
+
+	show := entity.Show{
+		ShowID:          uuid.NewString(),
+		NumberOfTickets: 2,
+	}
+	err := repoShows.Store(ctx, show)
+	require.NoError(t, err)
+
+	newHold := func(expiresAt time.Time) entity.SeatHold {
+		return entity.SeatHold{
+			HoldID:          uuid.NewString(),
+			ShowID:          show.ShowID,
+			NumberOfTickets: 2,
+			CustomerEmail:   "test@test.io",
+			ExpiresAt:       expiresAt,
+		}
+	}
+
+	t.Run("expired_hold_releases_seats", func(t *testing.T) {
+		hold := newHold(time.Now().Add(-time.Second))
+		err := repo.Hold(ctx, hold)
+		require.NoError(t, err)
+
+		_, err = repo.ConfirmHold(ctx, hold.HoldID, uuid.NewString())
+		assert.ErrorIs(t, err, entity.ErrSeatHoldExpired)
+
+		expired, err := repo.ExpireHolds(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, expired)
+
+		expired, err = repo.ExpireHolds(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, expired)
+	})
+
+	t.Run("active_hold_reserves_seats", func(t *testing.T) {
+		hold := newHold(time.Now().Add(time.Minute))
+		err := repo.Hold(ctx, hold)
+		require.NoError(t, err)
+
+		err = repo.Hold(ctx, newHold(time.Now().Add(time.Minute)))
+		assert.ErrorIs(t, err, entity.ErrNoAvailableTickets)
+
+		err = repo.Store(ctx, entity.Booking{
+			BookingID:       uuid.NewString(),
+			ShowID:          show.ShowID,
+			NumberOfTickets: 1,
+			CustomerEmail:   "test@test.io",
+		})
+		assert.ErrorIs(t, err, entity.ErrNoAvailableTickets)
+
+		bookingID := uuid.NewString()
+		booking, err := repo.ConfirmHold(ctx, hold.HoldID, bookingID)
+		require.NoError(t, err)
+		assert.Equal(t, bookingID, booking.BookingID)
+
+		// confirmation is idempotent
+		booking, err = repo.ConfirmHold(ctx, hold.HoldID, uuid.NewString())
+		require.NoError(t, err)
+		assert.Equal(t, bookingID, booking.BookingID)
+
+		storedBooking, err := repo.Get(ctx, bookingID)
+		require.NoError(t, err)
+		assert.Equal(t, booking, storedBooking)
+
+		_, err = repo.ConfirmHold(ctx, uuid.NewString(), uuid.NewString())
+		assert.ErrorIs(t, err, entity.ErrNotFound)
+	})
+	t.Run("hold_of_canceled_show_is_not_confirmed", func(t *testing.T) {
+		canceledShow := entity.Show{
+			ShowID:          uuid.NewString(),
+			NumberOfTickets: 2,
+		}
+		err := repoShows.Store(ctx, canceledShow)
+		require.NoError(t, err)
+
+		hold := newHold(time.Now().Add(time.Minute))
+		hold.ShowID = canceledShow.ShowID
+		hold.NumberOfTickets = 1
+		err = repo.Hold(ctx, hold)
+		require.NoError(t, err)
+
+		err = repoShows.Cancel(ctx, canceledShow.ShowID)
+		require.NoError(t, err)
+
+		var expired bool
+		err = db.GetDb(t).GetContext(ctx, &expired, `
+			SELECT expired_at IS NOT NULL FROM seat_holds WHERE hold_id = $1
+		`, hold.HoldID)
+		require.NoError(t, err)
+		assert.True(t, expired, "open holds should be expired when the show is canceled")
+
+		_, err = repo.ConfirmHold(ctx, hold.HoldID, uuid.NewString())
+		assert.ErrorIs(t, err, entity.ErrShowCanceled)
+
+		otherHold := newHold(time.Now().Add(time.Minute))
+		otherHold.ShowID = canceledShow.ShowID
+		otherHold.NumberOfTickets = 1
+		err = repo.Hold(ctx, otherHold)
+		assert.ErrorIs(t, err, entity.ErrShowCanceled)
+
+		err = repo.Store(ctx, entity.Booking{
+			BookingID:       uuid.NewString(),
+			ShowID:          canceledShow.ShowID,
+			NumberOfTickets: 1,
+			CustomerEmail:   "test@test.io",
+		})
+		assert.ErrorIs(t, err, entity.ErrShowCanceled)
+	})
+}
@@ -0,0 +1,300 @@
+package bookings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"tickets/db"
+	"tickets/entity"
+)
+
+const showSeatColumns = "section, seat_row, seat_number, category, booking_id, ticket_id"
+
+// SetSeatMap replaces seats of the show. The show's number of tickets is set to the number of seats.
+// It's not possible when any seats of the show are booked or held.
+func (r *PostgresRepository) SetSeatMap(ctx context.Context, showID string, seats []entity.ShowSeat) error {
+	return db.UpdateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			if _, err := r.lockShowInventory(ctx, tx, showID); err != nil {
+				return err
+			}
+
+			// bookings made before the seat map was set have no seats assigned
+			var inUse bool
+			err := tx.GetContext(ctx, &inUse, `
+				SELECT
+					EXISTS (
+						SELECT 1 FROM bookings WHERE show_id = $1 AND canceled_at IS NULL
+					) OR EXISTS (
+						SELECT 1 FROM seat_holds
+						WHERE show_id = $1 AND confirmed_at IS NULL AND expired_at IS NULL AND expires_at > NOW()
+					)
+			`, showID)
+			if err != nil {
+				return fmt.Errorf("could not check booked seats: %w", err)
+			}
+			if inUse {
+				return entity.ErrSeatMapInUse
+			}
+
+			_, err = tx.ExecContext(ctx, `DELETE FROM show_seats WHERE show_id = $1`, showID)
+			if err != nil {
+				return fmt.Errorf("could not delete seats: %w", err)
+			}
+
+			mapSeats := make([]entity.Seat, 0, len(seats))
+			categories := make([]string, 0, len(seats))
+			for _, seat := range seats {
+				mapSeats = append(mapSeats, seat.Seat)
+				categories = append(categories, seat.Category)
+			}
+			sections, rows, numbers := seatsColumns(mapSeats)
+
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO show_seats (show_id, section, seat_row, seat_number, category)
+				SELECT $1, s.section, s.seat_row, s.seat_number, s.category
+				FROM unnest($2::VARCHAR[], $3::VARCHAR[], $4::INT[], $5::VARCHAR[]) AS s(section, seat_row, seat_number, category)
+			`, showID, pq.Array(sections), pq.Array(rows), pq.Array(numbers), pq.Array(categories))
+			if err != nil {
+				return fmt.Errorf("could not add seats: %w", err)
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				UPDATE shows SET number_of_tickets = $2 WHERE show_id = $1
+			`, showID, len(seats))
+			if err != nil {
+				return fmt.Errorf("could not update number of tickets: %w", err)
+			}
+
+			return nil
+		},
+	)
+}
+
+// ShowSeats returns the show's seat map. It's empty for shows without a seat map.
+func (r *PostgresRepository) ShowSeats(ctx context.Context, showID string) ([]entity.ShowSeat, error) {
+	var seats []entity.ShowSeat
+	err := r.db.SelectContext(ctx, &seats, `
+		SELECT `+showSeatColumns+`
+		FROM show_seats
+		WHERE show_id = $1
+		ORDER BY section, seat_row, seat_number
+	`, showID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get seats: %w", err)
+	}
+
+	return seats, nil
+}
+
+// AssignTicketSeats assigns one of the booking's seats to each ticket and returns them in the order of tickets.
+// Assigning the seat again returns the same seat. Seat is nil if the ticket's booking has no seats left.
+//
+// The seats are assigned in one transaction, after validateFn accepts the ticket with its seat.
+// When validateFn returns an error for any ticket, no seat is assigned and the error is returned.
+func (r *PostgresRepository) AssignTicketSeats(
+	ctx context.Context,
+	tickets []entity.Ticket,
+	validateFn func(ticket entity.Ticket, seat *entity.Seat) error,
+) ([]*entity.Seat, error) {
+	var seats []*entity.Seat
+
+	err := db.UpdateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			seats = make([]*entity.Seat, 0, len(tickets))
+
+			for _, ticket := range tickets {
+				seat, err := r.assignTicketSeat(ctx, tx, ticket.BookingID, ticket.TicketID)
+				if err != nil {
+					return fmt.Errorf("could not assign seat to ticket %s: %w", ticket.TicketID, err)
+				}
+
+				if err := validateFn(ticket, seat); err != nil {
+					return err
+				}
+
+				seats = append(seats, seat)
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return seats, nil
+}
+
+func (r *PostgresRepository) assignTicketSeat(ctx context.Context, tx *sqlx.Tx, bookingID string, ticketID string) (*entity.Seat, error) {
+	var seat entity.Seat
+
+	err := tx.GetContext(ctx, &seat, `
+		SELECT section, seat_row, seat_number FROM show_seats WHERE ticket_id = $1
+	`, ticketID)
+	if err == nil {
+		return &seat, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("could not get ticket seat: %w", err)
+	}
+
+	if _, err := uuid.Parse(bookingID); err != nil {
+		// tickets booked outside of the service have no seats
+		return nil, nil
+	}
+
+	// tickets of the booking may be confirmed concurrently, so locked seats are skipped
+	err = tx.GetContext(ctx, &seat, `
+		UPDATE show_seats SET ticket_id = $2
+		WHERE (show_id, section, seat_row, seat_number) = (
+			SELECT show_id, section, seat_row, seat_number
+			FROM show_seats
+			WHERE booking_id = $1 AND ticket_id IS NULL
+			ORDER BY section, seat_row, seat_number
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING section, seat_row, seat_number
+	`, bookingID, ticketID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not assign ticket seat: %w", err)
+	}
+
+	return &seat, nil
+}
+
+// OnTicketRefunded releases the seat of the refunded ticket, so it can be booked again.
+func (r *PostgresRepository) OnTicketRefunded(ctx context.Context, event *entity.TicketRefunded_v1) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE show_seats SET booking_id = NULL, ticket_id = NULL WHERE ticket_id = $1
+	`, event.TicketID)
+	if err != nil {
+		return fmt.Errorf("could not release seat of ticket %s: %w", event.TicketID, err)
+	}
+
+	return nil
+}
+
+// allocateSeats books seats selected in the booking, or the first available seats when none are selected.
+// Shows without a seat map have no seats to allocate. The show's inventory must be locked.
+// It returns the allocated seats with their categories.
+func (r *PostgresRepository) allocateSeats(ctx context.Context, tx *sqlx.Tx, booking *entity.Booking) ([]entity.ShowSeat, error) {
+	var hasSeatMap bool
+	err := tx.GetContext(ctx, &hasSeatMap, `
+		SELECT EXISTS (SELECT 1 FROM show_seats WHERE show_id = $1)
+	`, booking.ShowID)
+	if err != nil {
+		return nil, fmt.Errorf("could not check seat map: %w", err)
+	}
+
+	if !hasSeatMap {
+		if len(booking.Seats) > 0 {
+			return nil, fmt.Errorf("%w: show has no seat map", entity.ErrSeatNotAvailable)
+		}
+		return nil, nil
+	}
+
+	var seats []entity.ShowSeat
+	if len(booking.Seats) > 0 {
+		sections, rows, numbers := seatsColumns(booking.Seats)
+		err = tx.SelectContext(ctx, &seats, `
+			UPDATE show_seats SET booking_id = $2
+			FROM unnest($3::VARCHAR[], $4::VARCHAR[], $5::INT[]) AS s(section, seat_row, seat_number)
+			WHERE show_seats.show_id = $1
+				AND show_seats.section = s.section
+				AND show_seats.seat_row = s.seat_row
+				AND show_seats.seat_number = s.seat_number
+				AND show_seats.booking_id IS NULL
+			RETURNING show_seats.section, show_seats.seat_row, show_seats.seat_number, show_seats.category
+		`, booking.ShowID, booking.BookingID, pq.Array(sections), pq.Array(rows), pq.Array(numbers))
+		if err != nil {
+			return nil, fmt.Errorf("could not book seats: %w", err)
+		}
+		if len(seats) != len(booking.Seats) {
+			return nil, entity.ErrSeatNotAvailable
+		}
+	} else {
+		err = tx.SelectContext(ctx, &seats, `
+			UPDATE show_seats SET booking_id = $2
+			WHERE (show_id, section, seat_row, seat_number) IN (
+				SELECT show_id, section, seat_row, seat_number
+				FROM show_seats
+				WHERE show_id = $1 AND booking_id IS NULL
+				ORDER BY section, seat_row, seat_number
+				LIMIT $3
+			)
+			RETURNING section, seat_row, seat_number, category
+		`, booking.ShowID, booking.BookingID, booking.NumberOfTickets)
+		if err != nil {
+			return nil, fmt.Errorf("could not book seats: %w", err)
+		}
+		// seats of refunded tickets may be not released yet
+		if len(seats) != booking.NumberOfTickets {
+			return nil, entity.ErrNoAvailableTickets
+		}
+	}
+
+	booking.Seats = make([]entity.Seat, 0, len(seats))
+	for _, seat := range seats {
+		booking.Seats = append(booking.Seats, seat.Seat)
+	}
+
+	return seats, nil
+}
+
+func (r *PostgresRepository) releaseBookingSeats(ctx context.Context, tx *sqlx.Tx, bookingID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE show_seats SET booking_id = NULL, ticket_id = NULL WHERE booking_id = $1
+	`, bookingID)
+	if err != nil {
+		return fmt.Errorf("could not release seats: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) bookingSeats(ctx context.Context, q sqlx.QueryerContext, bookingID string) ([]entity.Seat, error) {
+	var seats []entity.Seat
+	err := sqlx.SelectContext(ctx, q, &seats, `
+		SELECT section, seat_row, seat_number
+		FROM show_seats
+		WHERE booking_id = $1
+		ORDER BY section, seat_row, seat_number
+	`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get booking seats: %w", err)
+	}
+
+	return seats, nil
+}
+
+// seatsColumns splits seats into columns, which can be passed as arrays to unnest.
+func seatsColumns(seats []entity.Seat) ([]string, []string, []int) {
+	sections := make([]string, 0, len(seats))
+	rows := make([]string, 0, len(seats))
+	numbers := make([]int, 0, len(seats))
+
+	for _, seat := range seats {
+		sections = append(sections, seat.Section)
+		rows = append(rows, seat.Row)
+		numbers = append(numbers, seat.Number)
+	}
+
+	return sections, rows, numbers
+}
@@ -0,0 +1,155 @@
+package bookings
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tickets/db"
+	"tickets/db/shows"
+	"tickets/entity"
+)
+
+func TestPostgresRepository_Seats(t *testing.T) {
+	ctx := context.Background()
+	container, url := db.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+
+	repo := NewPostgresRepository(db.GetDb(t))
+	repoShows := shows.NewPostgresRepository(db.GetDb(t))
+
+	show := entity.Show{
+		ShowID:          uuid.NewString(),
+		NumberOfTickets: 100,
+	}
+	err := repoShows.Store(ctx, show)
+	require.NoError(t, err)
+
+	seatMap := entity.SeatMap{
+		Sections: []entity.SeatMapSection{
+			{
+				Name:     "A",
+				Category: "premium",
+				Rows:     []entity.SeatMapRow{{Row: "1", Seats: 2}},
+			},
+			{
+				Name: "B",
+				Rows: []entity.SeatMapRow{{Row: "1", Seats: 1}},
+			},
+		},
+	}
+	seats, err := seatMap.ShowSeats()
+	require.NoError(t, err)
+
+	err = repo.SetSeatMap(ctx, show.ShowID, seats)
+	require.NoError(t, err)
+
+	storedShow, err := repoShows.Get(ctx, show.ShowID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, storedShow.NumberOfTickets, "number of tickets should be set to the number of seats")
+
+	newBooking := func(numberOfTickets int, seats ...entity.Seat) entity.Booking {
+		return entity.Booking{
+			BookingID:       uuid.NewString(),
+			ShowID:          show.ShowID,
+			NumberOfTickets: numberOfTickets,
+			CustomerEmail:   "test@test.io",
+			Seats:           seats,
+		}
+	}
+
+	selectedSeat := entity.Seat{Section: "B", Row: "1", Number: 1}
+
+	selectedBooking := newBooking(1, selectedSeat)
+	err = repo.Store(ctx, selectedBooking)
+	require.NoError(t, err)
+
+	err = repo.Store(ctx, newBooking(1, selectedSeat))
+	assert.ErrorIs(t, err, entity.ErrSeatNotAvailable)
+
+	err = repo.SetSeatMap(ctx, show.ShowID, seats)
+	assert.ErrorIs(t, err, entity.ErrSeatMapInUse)
+
+	autoBooking := newBooking(2)
+	err = repo.Store(ctx, autoBooking)
+	require.NoError(t, err)
+
+	storedBooking, err := repo.Get(ctx, autoBooking.BookingID)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.Seat{
+		{Section: "A", Row: "1", Number: 1},
+		{Section: "A", Row: "1", Number: 2},
+	}, storedBooking.Seats)
+
+	assignSeat := func(ticketID string) (*entity.Seat, error) {
+		seats, err := repo.AssignTicketSeats(
+			ctx,
+			[]entity.Ticket{{TicketID: ticketID, BookingID: autoBooking.BookingID}},
+			func(entity.Ticket, *entity.Seat) error { return nil },
+		)
+		if err != nil {
+			return nil, err
+		}
+		return seats[0], nil
+	}
+
+	errInvalidPrice := errors.New("invalid price")
+	_, err = repo.AssignTicketSeats(
+		ctx,
+		[]entity.Ticket{
+			{TicketID: uuid.NewString(), BookingID: autoBooking.BookingID},
+			{TicketID: uuid.NewString(), BookingID: autoBooking.BookingID},
+		},
+		func(ticket entity.Ticket, seat *entity.Seat) error {
+			if seat.Number == 2 {
+				return errInvalidPrice
+			}
+			return nil
+		},
+	)
+	assert.ErrorIs(t, err, errInvalidPrice)
+
+	ticketID := uuid.NewString()
+	seat, err := assignSeat(ticketID)
+	require.NoError(t, err)
+	require.NotNil(t, seat)
+	assert.Equal(t, 1, seat.Number, "seats of rejected batch should not be assigned")
+
+	sameSeat, err := assignSeat(ticketID)
+	require.NoError(t, err)
+	assert.Equal(t, seat, sameSeat, "assigning should be idempotent")
+
+	otherSeat, err := assignSeat(uuid.NewString())
+	require.NoError(t, err)
+	require.NotNil(t, otherSeat)
+	assert.NotEqual(t, seat, otherSeat)
+
+	noSeat, err := assignSeat(uuid.NewString())
+	require.NoError(t, err)
+	assert.Nil(t, noSeat, "booking has no seats left")
+
+	err = repo.OnTicketRefunded(ctx, &entity.TicketRefunded_v1{Header: entity.NewEventHeader(), TicketID: ticketID})
+	require.NoError(t, err)
+
+	err = repo.Cancel(ctx, selectedBooking.BookingID)
+	require.NoError(t, err)
+
+	showSeats, err := repo.ShowSeats(ctx, show.ShowID)
+	require.NoError(t, err)
+
+	available := map[entity.Seat]bool{}
+	for _, showSeat := range showSeats {
+		available[showSeat.Seat] = showSeat.Available()
+	}
+	assert.Equal(t, map[entity.Seat]bool{
+		*seat:        true,
+		*otherSeat:   false,
+		selectedSeat: true,
+	}, available)
+}
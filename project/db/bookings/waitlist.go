@@ -0,0 +1,163 @@
+package bookings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"tickets/db"
+	"tickets/entity"
+	"tickets/pubsub/outbox"
+)
+
+const waitlistEntrySelect = `
+	SELECT
+		w.entry_id, w.show_id, w.number_of_tickets, w.customer_email, w.created_at,
+		w.hold_id, w.offered_at, h.expires_at AS offer_expires_at, h.booking_id,
+		CASE
+			WHEN w.hold_id IS NULL THEN '` + entity.WaitlistEntryStatusWaiting + `'
+			WHEN h.confirmed_at IS NOT NULL THEN '` + entity.WaitlistEntryStatusAccepted + `'
+			WHEN h.expired_at IS NOT NULL OR h.expires_at <= NOW() THEN '` + entity.WaitlistEntryStatusExpired + `'
+			ELSE '` + entity.WaitlistEntryStatusOffered + `'
+		END AS status
+	FROM waitlist_entries w
+	LEFT JOIN seat_holds h ON h.hold_id = w.hold_id
+`
+
+// JoinWaitlist adds the customer to the end of the show's waitlist and publishes WaitlistJoined_v1.
+func (r *PostgresRepository) JoinWaitlist(ctx context.Context, entry entity.WaitlistEntry) error {
+	return db.UpdateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			_, err := tx.NamedExecContext(ctx, `
+				INSERT INTO waitlist_entries (entry_id, show_id, number_of_tickets, customer_email)
+				VALUES (:entry_id, :show_id, :number_of_tickets, :customer_email)
+			`, entry)
+			if err != nil {
+				return fmt.Errorf("could not add waitlist entry: %w", err)
+			}
+
+			eventBus, err := outbox.NewEventBusForTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			err = eventBus.Publish(ctx, entity.WaitlistJoined_v1{
+				Header:          entity.NewEventHeader(),
+				EntryID:         entry.EntryID,
+				ShowID:          entry.ShowID,
+				NumberOfTickets: entry.NumberOfTickets,
+				CustomerEmail:   entry.CustomerEmail,
+			})
+			if err != nil {
+				return fmt.Errorf("could not publish event: %w", err)
+			}
+
+			return nil
+		},
+	)
+}
+
+func (r *PostgresRepository) GetWaitlistEntry(ctx context.Context, entryID string) (entity.WaitlistEntry, error) {
+	var entry entity.WaitlistEntry
+	err := r.db.GetContext(ctx, &entry, waitlistEntrySelect+`WHERE w.entry_id = $1`, entryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.WaitlistEntry{}, entity.ErrNotFound
+	}
+	if err != nil {
+		return entity.WaitlistEntry{}, fmt.Errorf("could not get waitlist entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// OfferWaitlist offers free seats of the show to waiting customers, in the order they joined the waitlist.
+// Each offer is a seat hold, which expires after offerTTL. Offering stops at the first customer
+// who can't be offered all requested seats, so customers who joined later don't jump the queue.
+// It publishes WaitlistOfferMade_v1 for each offer and returns the number of offers made.
+func (r *PostgresRepository) OfferWaitlist(ctx context.Context, showID string, offerTTL time.Duration) (int, error) {
+	var offers int
+
+	err := db.UpdateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			offers = 0
+
+			for {
+				// the lock makes concurrent offering wait, so the same customer is not offered seats twice
+				var entry entity.WaitlistEntry
+				err := tx.GetContext(ctx, &entry, `
+					SELECT entry_id, show_id, number_of_tickets, customer_email, created_at
+					FROM waitlist_entries
+					WHERE show_id = $1 AND hold_id IS NULL
+					ORDER BY created_at, entry_id
+					LIMIT 1
+					FOR UPDATE
+				`, showID)
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("could not get waiting customer: %w", err)
+				}
+
+				hold := entity.SeatHold{
+					HoldID:          uuid.NewString(),
+					ShowID:          showID,
+					NumberOfTickets: entry.NumberOfTickets,
+					CustomerEmail:   entry.CustomerEmail,
+					ExpiresAt:       time.Now().UTC().Add(offerTTL).Truncate(time.Microsecond),
+				}
+
+				err = r.hold(ctx, tx, hold)
+				if errors.Is(err, entity.ErrNoAvailableTickets) || errors.Is(err, entity.ErrShowCanceled) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+
+				_, err = tx.ExecContext(ctx, `
+					UPDATE waitlist_entries SET hold_id = $2, offered_at = NOW() WHERE entry_id = $1
+				`, entry.EntryID, hold.HoldID)
+				if err != nil {
+					return fmt.Errorf("could not update waitlist entry: %w", err)
+				}
+
+				eventBus, err := outbox.NewEventBusForTx(ctx, tx)
+				if err != nil {
+					return err
+				}
+
+				err = eventBus.Publish(ctx, entity.WaitlistOfferMade_v1{
+					Header:          entity.NewEventHeader(),
+					EntryID:         entry.EntryID,
+					ShowID:          showID,
+					HoldID:          hold.HoldID,
+					NumberOfTickets: hold.NumberOfTickets,
+					CustomerEmail:   hold.CustomerEmail,
+					ExpiresAt:       hold.ExpiresAt,
+				})
+				if err != nil {
+					return fmt.Errorf("could not publish event: %w", err)
+				}
+
+				offers++
+			}
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return offers, nil
+}
@@ -0,0 +1,99 @@
+package bookings
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tickets/db"
+	"tickets/db/shows"
+	"tickets/entity"
+)
+
+func TestPostgresRepository_Waitlist(t *testing.T) {
+	ctx := context.Background()
+	container, url := db.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+
+	repo := NewPostgresRepository(db.GetDb(t))
+	repoShows := shows.NewPostgresRepository(db.GetDb(t))
+
+	show := entity.Show{
+		ShowID:          uuid.NewString(),
+		NumberOfTickets: 2,
+	}
+	err := repoShows.Store(ctx, show)
+	require.NoError(t, err)
+
+	booking := entity.Booking{
+		BookingID:       uuid.NewString(),
+		ShowID:          show.ShowID,
+		NumberOfTickets: 2,
+		CustomerEmail:   "booked@test.io",
+	}
+	err = repo.Store(ctx, booking)
+	require.NoError(t, err)
+
+	joinWaitlist := func(numberOfTickets int) string {
+		entry := entity.WaitlistEntry{
+			EntryID:         uuid.NewString(),
+			ShowID:          show.ShowID,
+			NumberOfTickets: numberOfTickets,
+			CustomerEmail:   "waiting@test.io",
+		}
+		err := repo.JoinWaitlist(ctx, entry)
+		require.NoError(t, err)
+		return entry.EntryID
+	}
+
+	firstEntryID := joinWaitlist(2)
+	secondEntryID := joinWaitlist(1)
+
+	offers, err := repo.OfferWaitlist(ctx, show.ShowID, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 0, offers, "show is sold out")
+
+	err = repo.Cancel(ctx, booking.BookingID)
+	require.NoError(t, err)
+
+	offers, err = repo.OfferWaitlist(ctx, show.ShowID, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, offers, "seats are offered in the order customers joined the waitlist")
+
+	firstEntry, err := repo.GetWaitlistEntry(ctx, firstEntryID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.WaitlistEntryStatusOffered, firstEntry.Status)
+	require.NotNil(t, firstEntry.HoldID)
+
+	entry, err := repo.GetWaitlistEntry(ctx, secondEntryID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.WaitlistEntryStatusWaiting, entry.Status)
+
+	acceptedBooking, err := repo.ConfirmHold(ctx, *firstEntry.HoldID, uuid.NewString())
+	require.NoError(t, err)
+
+	entry, err = repo.GetWaitlistEntry(ctx, firstEntryID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.WaitlistEntryStatusAccepted, entry.Status)
+	assert.Equal(t, &acceptedBooking.BookingID, entry.BookingID)
+
+	err = repo.Cancel(ctx, acceptedBooking.BookingID)
+	require.NoError(t, err)
+
+	offers, err = repo.OfferWaitlist(ctx, show.ShowID, -time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, offers)
+
+	entry, err = repo.GetWaitlistEntry(ctx, secondEntryID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.WaitlistEntryStatusExpired, entry.Status)
+
+	_, err = repo.GetWaitlistEntry(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, entity.ErrNotFound)
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"tickets/entity"
+)
+
+// EncodeCursor builds an opaque keyset pagination cursor pointing at the last returned row.
+// Postgres stores timestamps with microsecond precision, so the cursor is truncated to match the stored value.
+func EncodeCursor(sortValue time.Time, id string) string {
+	raw := sortValue.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("%w: %s", entity.ErrInvalidCursor, err)
+	}
+
+	sortValue, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return time.Time{}, "", entity.ErrInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, sortValue)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("%w: %s", entity.ErrInvalidCursor, err)
+	}
+
+	return t, id, nil
+}
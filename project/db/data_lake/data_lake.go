@@ -31,9 +31,9 @@ func (s DataLake) StoreEvent(
 		ctx,
 		`
 			INSERT INTO 
-			    events (event_id, published_at, event_name, event_payload) 
+			    events (event_id, published_at, event_name, event_payload, correlation_id, causation_id) 
 			VALUES 
-			    (:event_id, :published_at, :event_name, :event_payload)`,
+			    (:event_id, :published_at, :event_name, :event_payload, :correlation_id, :causation_id)`,
 		dataLakeEvent,
 	)
 	var postgresError *pq.Error
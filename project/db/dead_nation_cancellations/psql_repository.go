@@ -0,0 +1,54 @@
+package dead_nation_cancellations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresRepository stores bookings which must be canceled at Dead Nation manually.
+// Dead Nation API doesn't support booking cancellation, so the operations team cancels the stored bookings
+// and marks them as canceled.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	if db == nil {
+		panic("db must be set")
+	}
+
+	return &PostgresRepository{db: db}
+}
+
+// RequestManualCancellation records the booking to be canceled at Dead Nation.
+// Requesting cancellation of an already recorded booking does nothing.
+func (r *PostgresRepository) RequestManualCancellation(ctx context.Context, bookingID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO dead_nation_manual_cancellations (booking_id)
+		VALUES ($1)
+		ON CONFLICT (booking_id) DO NOTHING
+	`, bookingID)
+	if err != nil {
+		return fmt.Errorf("could not request manual cancellation of booking %s: %w", bookingID, err)
+	}
+
+	return nil
+}
+
+// Pending returns IDs of bookings which still need to be canceled at Dead Nation, the oldest first.
+func (r *PostgresRepository) Pending(ctx context.Context) ([]string, error) {
+	var bookingIDs []string
+	err := r.db.SelectContext(ctx, &bookingIDs, `
+		SELECT booking_id
+		FROM dead_nation_manual_cancellations
+		WHERE canceled_at IS NULL
+		ORDER BY requested_at, booking_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("could not get pending manual cancellations: %w", err)
+	}
+
+	return bookingIDs, nil
+}
@@ -0,0 +1,33 @@
+package dead_nation_cancellations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbutils "tickets/db"
+)
+
+func TestPostgresRepository_RequestManualCancellation(t *testing.T) {
+	ctx := context.Background()
+	container, url := dbutils.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+	repo := NewPostgresRepository(dbutils.GetDb(t))
+
+	bookingID := uuid.NewString()
+
+	// redelivered event requests the cancellation again
+	for i := 0; i < 2; i++ {
+		err := repo.RequestManualCancellation(ctx, bookingID)
+		require.NoError(t, err)
+	}
+
+	pending, err := repo.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{bookingID}, pending)
+}
@@ -0,0 +1,107 @@
+package idempotency_keys
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"tickets/entity"
+)
+
+// abandonedRequestTimeout is the time after which a request that never completed (for example, because
+// the service crashed) no longer blocks the idempotency key.
+const abandonedRequestTimeout = "1 minute"
+
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	if db == nil {
+		panic("db must be set")
+	}
+
+	return &PostgresRepository{db: db}
+}
+
+// Begin reserves the idempotency key for the request.
+// It returns a nil response when the request should be processed, or the stored response when it was
+// already processed and should be replayed.
+func (r *PostgresRepository) Begin(ctx context.Context, idempotencyKey string, requestHash string) (*entity.IdempotentResponse, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO http_idempotency_keys (idempotency_key, request_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (idempotency_key) DO UPDATE SET created_at = NOW()
+		WHERE
+			http_idempotency_keys.response_status IS NULL
+			AND http_idempotency_keys.request_hash = excluded.request_hash
+			AND http_idempotency_keys.created_at < NOW() - INTERVAL '`+abandonedRequestTimeout+`'
+	`, idempotencyKey, requestHash)
+	if err != nil {
+		return nil, fmt.Errorf("could not reserve idempotency key: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 1 {
+		return nil, nil
+	}
+
+	var stored struct {
+		RequestHash         string         `db:"request_hash"`
+		ResponseStatus      sql.NullInt32  `db:"response_status"`
+		ResponseContentType sql.NullString `db:"response_content_type"`
+		ResponseBody        []byte         `db:"response_body"`
+	}
+	err = r.db.GetContext(ctx, &stored, `
+		SELECT request_hash, response_status, response_content_type, response_body
+		FROM http_idempotency_keys
+		WHERE idempotency_key = $1
+	`, idempotencyKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not get idempotency key: %w", err)
+	}
+
+	if stored.RequestHash != requestHash {
+		return nil, entity.ErrIdempotencyKeyReused
+	}
+	if !stored.ResponseStatus.Valid {
+		return nil, entity.ErrIdempotentRequestInFlight
+	}
+
+	return &entity.IdempotentResponse{
+		StatusCode:  int(stored.ResponseStatus.Int32),
+		ContentType: stored.ResponseContentType.String,
+		Body:        stored.ResponseBody,
+	}, nil
+}
+
+func (r *PostgresRepository) Complete(ctx context.Context, idempotencyKey string, response entity.IdempotentResponse) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE http_idempotency_keys
+		SET response_status = $2, response_content_type = $3, response_body = $4
+		WHERE idempotency_key = $1
+	`, idempotencyKey, response.StatusCode, response.ContentType, response.Body)
+	if err != nil {
+		return fmt.Errorf("could not store response for idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Release frees the idempotency key of a request that failed, so the client can retry it.
+func (r *PostgresRepository) Release(ctx context.Context, idempotencyKey string) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM http_idempotency_keys
+		WHERE idempotency_key = $1 AND response_status IS NULL
+	`, idempotencyKey)
+	if err != nil {
+		return fmt.Errorf("could not release idempotency key: %w", err)
+	}
+
+	return nil
+}
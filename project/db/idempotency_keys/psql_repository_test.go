@@ -0,0 +1,64 @@
+package idempotency_keys
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbutils "tickets/db"
+	"tickets/entity"
+)
+
+func TestPostgresRepository(t *testing.T) {
+	ctx := context.Background()
+	container, url := dbutils.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+	repo := NewPostgresRepository(dbutils.GetDb(t))
+
+	t.Run("replay", func(t *testing.T) {
+		idempotencyKey := uuid.NewString()
+
+		stored, err := repo.Begin(ctx, idempotencyKey, "hash")
+		require.NoError(t, err)
+		require.Nil(t, stored, "first request should be processed")
+
+		_, err = repo.Begin(ctx, idempotencyKey, "hash")
+		assert.ErrorIs(t, err, entity.ErrIdempotentRequestInFlight)
+
+		response := entity.IdempotentResponse{
+			StatusCode:  http.StatusCreated,
+			ContentType: "application/json",
+			Body:        []byte(`{"booking_id":"1"}`),
+		}
+		err = repo.Complete(ctx, idempotencyKey, response)
+		require.NoError(t, err)
+
+		stored, err = repo.Begin(ctx, idempotencyKey, "hash")
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, response, *stored)
+
+		_, err = repo.Begin(ctx, idempotencyKey, "other-hash")
+		assert.ErrorIs(t, err, entity.ErrIdempotencyKeyReused)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		idempotencyKey := uuid.NewString()
+
+		_, err := repo.Begin(ctx, idempotencyKey, "hash")
+		require.NoError(t, err)
+
+		err = repo.Release(ctx, idempotencyKey)
+		require.NoError(t, err)
+
+		stored, err := repo.Begin(ctx, idempotencyKey, "hash")
+		require.NoError(t, err)
+		assert.Nil(t, stored, "released request should be processed again")
+	})
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"github.com/shopspring/decimal"
+
+	"tickets/entity"
+)
+
+// PriceColumns maps entity.Money to the price_amount and price_currency columns.
+// It's embedded in row structs, so the price is scanned and inserted with sqlx.
+type PriceColumns struct {
+	PriceAmount   decimal.Decimal `db:"price_amount"`
+	PriceCurrency string          `db:"price_currency"`
+}
+
+func NewPriceColumns(price entity.Money) PriceColumns {
+	return PriceColumns{
+		PriceAmount:   price.Amount,
+		PriceCurrency: price.Currency,
+	}
+}
+
+func (c PriceColumns) Money() entity.Money {
+	return entity.Money{
+		Amount:   c.PriceAmount,
+		Currency: c.PriceCurrency,
+	}
+}
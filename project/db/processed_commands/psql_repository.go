@@ -0,0 +1,93 @@
+package processed_commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"tickets/entity"
+)
+
+// abandonedCommandTimeout is the time after which a command that was never completed (for example, because
+// the service crashed) can be handled again.
+const abandonedCommandTimeout = "1 minute"
+
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	if db == nil {
+		panic("db must be set")
+	}
+
+	return &PostgresRepository{db: db}
+}
+
+// Begin reserves the command for the handler.
+// It returns true when the command should be handled, or false when the handler already completed it.
+// It returns ErrCommandInFlight when the command is still being handled.
+func (r *PostgresRepository) Begin(ctx context.Context, handlerName string, commandID string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO processed_commands (command_id, handler_name)
+		VALUES ($1, $2)
+		ON CONFLICT (command_id, handler_name) DO UPDATE SET created_at = NOW()
+		WHERE
+			processed_commands.completed_at IS NULL
+			AND processed_commands.created_at < NOW() - INTERVAL '`+abandonedCommandTimeout+`'
+	`, commandID, handlerName)
+	if err != nil {
+		return false, fmt.Errorf("could not reserve command: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 1 {
+		return true, nil
+	}
+
+	var completed bool
+	err = r.db.GetContext(ctx, &completed, `
+		SELECT completed_at IS NOT NULL
+		FROM processed_commands
+		WHERE command_id = $1 AND handler_name = $2
+	`, commandID, handlerName)
+	if err != nil {
+		return false, fmt.Errorf("could not get processed command: %w", err)
+	}
+
+	if !completed {
+		return false, entity.ErrCommandInFlight
+	}
+
+	return false, nil
+}
+
+func (r *PostgresRepository) Complete(ctx context.Context, handlerName string, commandID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE processed_commands
+		SET completed_at = NOW()
+		WHERE command_id = $1 AND handler_name = $2
+	`, commandID, handlerName)
+	if err != nil {
+		return fmt.Errorf("could not complete command: %w", err)
+	}
+
+	return nil
+}
+
+// Release frees the command which failed to be handled, so it's handled again when it's redelivered.
+func (r *PostgresRepository) Release(ctx context.Context, handlerName string, commandID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM processed_commands
+		WHERE command_id = $1 AND handler_name = $2 AND completed_at IS NULL
+	`, commandID, handlerName)
+	if err != nil {
+		return fmt.Errorf("could not release command: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package processed_commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbutils "tickets/db"
+	"tickets/entity"
+)
+
+func TestPostgresRepository(t *testing.T) {
+	ctx := context.Background()
+	container, url := dbutils.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+	repo := NewPostgresRepository(dbutils.GetDb(t))
+
+	t.Run("deduplication", func(t *testing.T) {
+		commandID := uuid.NewString()
+
+		begun, err := repo.Begin(ctx, "BookShowTicketsHandler", commandID)
+		require.NoError(t, err)
+		require.True(t, begun, "first delivery should be handled")
+
+		_, err = repo.Begin(ctx, "BookShowTicketsHandler", commandID)
+		assert.ErrorIs(t, err, entity.ErrCommandInFlight)
+
+		err = repo.Complete(ctx, "BookShowTicketsHandler", commandID)
+		require.NoError(t, err)
+
+		begun, err = repo.Begin(ctx, "BookShowTicketsHandler", commandID)
+		require.NoError(t, err)
+		assert.False(t, begun, "redelivered command should be skipped")
+
+		begun, err = repo.Begin(ctx, "OtherHandler", commandID)
+		require.NoError(t, err)
+		assert.True(t, begun, "commands are deduplicated per handler")
+	})
+
+	t.Run("release", func(t *testing.T) {
+		commandID := uuid.NewString()
+
+		_, err := repo.Begin(ctx, "BookShowTicketsHandler", commandID)
+		require.NoError(t, err)
+
+		err = repo.Release(ctx, "BookShowTicketsHandler", commandID)
+		require.NoError(t, err)
+
+		begun, err := repo.Begin(ctx, "BookShowTicketsHandler", commandID)
+		require.NoError(t, err)
+		assert.True(t, begun, "failed command should be handled again")
+	})
+}
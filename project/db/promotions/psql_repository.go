@@ -0,0 +1,163 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+
+	"tickets/entity"
+)
+
+const promotionColumns = "code, discount_type, percentage, amount, currency, show_id, valid_from, valid_until, usage_limit, used_count"
+
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+type promotionRow struct {
+	Code         string              `db:"code"`
+	DiscountType string              `db:"discount_type"`
+	Percentage   decimal.NullDecimal `db:"percentage"`
+	Amount       decimal.NullDecimal `db:"amount"`
+	Currency     sql.NullString      `db:"currency"`
+	ShowID       *string             `db:"show_id"`
+	ValidFrom    *time.Time          `db:"valid_from"`
+	ValidUntil   *time.Time          `db:"valid_until"`
+	UsageLimit   *int                `db:"usage_limit"`
+	UsedCount    int                 `db:"used_count"`
+}
+
+func newPromotionRow(promotion entity.Promotion) promotionRow {
+	row := promotionRow{
+		Code:         promotion.Code,
+		DiscountType: promotion.Discount.Type,
+		ShowID:       promotion.ShowID,
+		ValidFrom:    promotion.ValidFrom,
+		ValidUntil:   promotion.ValidUntil,
+		UsageLimit:   promotion.UsageLimit,
+		UsedCount:    promotion.UsedCount,
+	}
+	if promotion.Discount.Percentage != nil {
+		row.Percentage = decimal.NewNullDecimal(*promotion.Discount.Percentage)
+	}
+	if promotion.Discount.Amount != nil {
+		row.Amount = decimal.NewNullDecimal(promotion.Discount.Amount.Amount)
+		row.Currency = sql.NullString{String: promotion.Discount.Amount.Currency, Valid: true}
+	}
+
+	return row
+}
+
+func (r promotionRow) promotion() entity.Promotion {
+	promotion := entity.Promotion{
+		Code: r.Code,
+		Discount: entity.Discount{
+			PromoCode: r.Code,
+			Type:      r.DiscountType,
+		},
+		ShowID:     r.ShowID,
+		ValidFrom:  r.ValidFrom,
+		ValidUntil: r.ValidUntil,
+		UsageLimit: r.UsageLimit,
+		UsedCount:  r.UsedCount,
+	}
+	if r.Percentage.Valid {
+		promotion.Discount.Percentage = &r.Percentage.Decimal
+	}
+	if r.Amount.Valid {
+		promotion.Discount.Amount = &entity.Money{
+			Amount:   r.Amount.Decimal,
+			Currency: r.Currency.String,
+		}
+	}
+
+	return promotion
+}
+
+// Store adds a new promotion. It returns ErrConflict when a promotion with the same code already exists.
+func (r *PostgresRepository) Store(ctx context.Context, promotion entity.Promotion) error {
+	res, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO promotions (`+promotionColumns+`)
+		VALUES (:code, :discount_type, :percentage, :amount, :currency, :show_id, :valid_from, :valid_until, :usage_limit, :used_count)
+		ON CONFLICT (code) DO NOTHING
+	`, newPromotionRow(promotion))
+	if err != nil {
+		return fmt.Errorf("could not add promotion: %w", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return fmt.Errorf("%w: promotion %s already exists", entity.ErrConflict, promotion.Code)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, code string) (entity.Promotion, error) {
+	return getPromotion(ctx, r.db, code, false)
+}
+
+// Redeem uses the promo code for a booking of the show and returns its discount.
+// The promotion is locked until the end of the transaction, so concurrent bookings can't exceed its usage limit.
+func Redeem(ctx context.Context, tx *sqlx.Tx, code string, showID string) (entity.Discount, error) {
+	promotion, err := getPromotion(ctx, tx, code, true)
+	if errors.Is(err, entity.ErrNotFound) {
+		return entity.Discount{}, fmt.Errorf("%w: %s doesn't exist", entity.ErrInvalidPromoCode, code)
+	}
+	if err != nil {
+		return entity.Discount{}, err
+	}
+
+	if err := promotion.CheckApplicable(showID, time.Now()); err != nil {
+		return entity.Discount{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE promotions SET used_count = used_count + 1 WHERE code = $1`, code)
+	if err != nil {
+		return entity.Discount{}, fmt.Errorf("could not redeem promotion: %w", err)
+	}
+
+	return promotion.Discount, nil
+}
+
+// Release gives back the use of the promo code by a canceled booking.
+func Release(ctx context.Context, tx *sqlx.Tx, code string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE promotions SET used_count = used_count - 1 WHERE code = $1 AND used_count > 0
+	`, code)
+	if err != nil {
+		return fmt.Errorf("could not release promotion: %w", err)
+	}
+
+	return nil
+}
+
+func getPromotion(ctx context.Context, q sqlx.QueryerContext, code string, forUpdate bool) (entity.Promotion, error) {
+	query := "SELECT " + promotionColumns + " FROM promotions WHERE code = $1"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var row promotionRow
+	err := sqlx.GetContext(ctx, q, &row, query, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Promotion{}, entity.ErrNotFound
+	}
+	if err != nil {
+		return entity.Promotion{}, fmt.Errorf("could not get promotion: %w", err)
+	}
+
+	return row.promotion(), nil
+}
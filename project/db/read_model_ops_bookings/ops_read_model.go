@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
@@ -15,6 +16,14 @@ import (
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultListLimit = 50
+
+	// maxUpdatedSinceBookings limits how many missed updates are replayed to a resumed stream.
+	maxUpdatedSinceBookings = 1000
 )
 
 type OpsBookingReadModel struct {
@@ -33,61 +42,200 @@ func NewOpsBookingReadModel(db *sqlx.DB, eventBus *cqrs.EventBus) OpsBookingRead
 	return OpsBookingReadModel{db: db, eventBus: eventBus}
 }
 
-func (r OpsBookingReadModel) AllReservations(receiptIssueDateFilter string) ([]entity.OpsBooking, error) {
+// AllReservations returns bookings matching the filter, paginated with a keyset cursor.
+// The returned cursor is empty when there are no more bookings to fetch.
+func (r OpsBookingReadModel) AllReservations(ctx context.Context, filter entity.OpsBookingsFilter) ([]entity.OpsBooking, string, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.CustomerEmail != "" {
+		addCondition("customer_email = $%d", filter.CustomerEmail)
+	}
+	if filter.ShowID != "" {
+		addCondition("show_id = $%d", filter.ShowID)
+	}
+	if filter.BookedFrom != nil {
+		addCondition("booked_at >= $%d", *filter.BookedFrom)
+	}
+	if filter.BookedTo != nil {
+		addCondition("booked_at <= $%d", *filter.BookedTo)
+	}
+	if filter.HasRefunds != nil {
+		addCondition("has_refunds = $%d", *filter.HasRefunds)
+	}
+	if filter.Printed != nil {
+		addCondition("printed = $%d", *filter.Printed)
+	}
+	if filter.ReceiptIssueDate != "" {
+		addCondition("receipt_issue_dates @> ARRAY[$%d::DATE]", filter.ReceiptIssueDate)
+	}
+
+	order, cursorOperator := "DESC", "<"
+	if filter.Sort == entity.OpsBookingsSortBookedAtAsc {
+		order, cursorOperator = "ASC", ">"
+	}
+
+	if filter.Cursor != "" {
+		bookedAt, bookingID, err := db.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		args = append(args, bookedAt, bookingID)
+		conditions = append(conditions, fmt.Sprintf("(booked_at, booking_id) %s ($%d, $%d)", cursorOperator, len(args)-1, len(args)))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
 	query := "SELECT payload FROM read_model_ops_bookings"
-	var quaryArgs []any
-
-	if receiptIssueDateFilter != "" {
-		// please keep in mind that this is not the most efficient way to do it
-		query += fmt.Sprintf(`
-			WHERE booking_id IN (
-				SELECT booking_id FROM (
-					SELECT booking_id, 
-						DATE(jsonb_path_query(payload, '$.tickets.*.receipt_issued_at')::text) as receipt_issued_at 
-					FROM 
-						read_model_ops_bookings
-				) bookings_within_date 
-				WHERE receipt_issued_at = $1
-			)
-		`)
-		quaryArgs = append(quaryArgs, receiptIssueDateFilter)
-	}
-
-	rows, err := r.db.Query(query, quaryArgs...)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// fetching one more row to know if there is a next page
+	query += fmt.Sprintf(" ORDER BY booked_at %s, booking_id %s LIMIT %d", order, order, limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("could not query read model: %w", err)
 	}
 	defer rows.Close()
 
-	var result []entity.OpsBooking
+	result := []entity.OpsBooking{}
 	for rows.Next() {
 		var payload []byte
 		if err := rows.Scan(&payload); err != nil {
+			return nil, "", err
+		}
+
+		reservation, err := r.unmarshalReadModelFromDB(payload)
+		if err != nil {
+			return nil, "", err
+		}
+
+		result = append(result, reservation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(result) > limit {
+		result = result[:limit]
+		last := result[len(result)-1]
+		nextCursor = db.EncodeCursor(last.BookedAt, last.BookingID)
+	}
+
+	return result, nextCursor, nil
+}
+
+// UpdatesPosition returns the current position of the updates stream.
+func (r OpsBookingReadModel) UpdatesPosition(ctx context.Context) (string, error) {
+	var position updatesPosition
+
+	// transactions started after the oldest running one may be still running as well,
+	// so their updates are returned after this position
+	err := r.db.QueryRowContext(ctx, "SELECT pg_snapshot_xmin(pg_current_snapshot())::TEXT").Scan(&position.TransactionID)
+	if err != nil {
+		return "", fmt.Errorf("could not get current snapshot: %w", err)
+	}
+
+	return position.String(), nil
+}
+
+// UpdatedSince returns bookings updated after the position of the updates stream, in the order of updates.
+// When bookingID is not empty, only this booking is returned.
+//
+// Updates are ordered by the transaction which wrote them, and returned only when all older transactions
+// are finished, so an update committed later than a newer one is never skipped.
+func (r OpsBookingReadModel) UpdatedSince(ctx context.Context, position string, bookingID string) ([]entity.OpsBookingUpdate, error) {
+	since, err := parseUpdatesPosition(position)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT payload, update_transaction_id::TEXT, update_position FROM read_model_ops_bookings
+		WHERE (update_transaction_id, update_position) > ($1::XID8, $2)
+		AND update_transaction_id < pg_snapshot_xmin(pg_current_snapshot())`
+	args := []any{since.TransactionID, since.Position}
+
+	if bookingID != "" {
+		query += " AND booking_id = $3"
+		args = append(args, bookingID)
+	}
+	query += fmt.Sprintf(" ORDER BY update_transaction_id, update_position LIMIT %d", maxUpdatedSinceBookings)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query read model: %w", err)
+	}
+	defer rows.Close()
+
+	var result []entity.OpsBookingUpdate
+	for rows.Next() {
+		var payload []byte
+		var position updatesPosition
+		if err := rows.Scan(&payload, &position.TransactionID, &position.Position); err != nil {
 			return nil, err
 		}
 
-		var reservation entity.OpsBooking
-		if err := json.Unmarshal(payload, &reservation); err != nil {
+		reservation, err := r.unmarshalReadModelFromDB(payload)
+		if err != nil {
 			return nil, err
 		}
 
-		result = append(result, reservation)
+		result = append(result, entity.OpsBookingUpdate{
+			Booking:  reservation,
+			Position: position.String(),
+		})
 	}
 
-	return result, nil
+	return result, rows.Err()
 }
 
 func (r OpsBookingReadModel) ReservationReadModel(ctx context.Context, bookingID string) (entity.OpsBooking, error) {
-	return r.findReadModelByBookingID(ctx, bookingID, r.db)
+	rm, err := r.findReadModelByBookingID(ctx, bookingID, r.db)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.OpsBooking{}, fmt.Errorf("booking %s: %w", bookingID, entity.ErrNotFound)
+	}
+
+	return rm, err
+}
+
+// TicketReadModel returns the ticket from the booking's read model.
+func (r OpsBookingReadModel) TicketReadModel(ctx context.Context, ticketID string) (entity.OpsTicket, error) {
+	rm, err := r.findReadModelByTicketID(ctx, ticketID, r.db)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.OpsTicket{}, fmt.Errorf("ticket %s: %w", ticketID, entity.ErrNotFound)
+	} else if err != nil {
+		return entity.OpsTicket{}, fmt.Errorf("could not find read model: %w", err)
+	}
+
+	ticket, ok := rm.Tickets[ticketID]
+	if !ok {
+		return entity.OpsTicket{}, fmt.Errorf("ticket %s: %w", ticketID, entity.ErrNotFound)
+	}
+
+	return ticket, nil
 }
 
 func (r OpsBookingReadModel) OnBookingMade(ctx context.Context, bookingMade *entity.BookingMade_v1) error {
 	// this is the first event that should arrive, so we create the read model
 	err := r.createReadModel(ctx, entity.OpsBooking{
-		BookingID:  bookingMade.BookingID,
-		Tickets:    nil,
-		LastUpdate: time.Now(),
-		BookedAt:   bookingMade.Header.PublishedAt,
+		BookingID:     bookingMade.BookingID,
+		ShowID:        bookingMade.ShowID,
+		CustomerEmail: bookingMade.CustomerEmail,
+		Tickets:       nil,
+		LastUpdate:    time.Now().UTC().Truncate(time.Microsecond),
+		BookedAt:      bookingMade.Header.PublishedAt,
 	})
 	if err != nil {
 		return fmt.Errorf("could not create read model: %w", err)
@@ -107,11 +255,10 @@ func (r OpsBookingReadModel) OnTicketBookingConfirmed(ctx context.Context, event
 				log.
 					FromContext(ctx).
 					WithField("ticket_id", event.TicketID).
-					Debug("Creating ticket read model for ticket %s")
+					Debug("Creating ticket read model")
 			}
 
-			ticket.PriceAmount = event.Price.Amount
-			ticket.PriceCurrency = event.Price.Currency
+			ticket.Price = event.Price
 			ticket.CustomerEmail = event.CustomerEmail
 			ticket.ConfirmedAt = event.Header.PublishedAt
 
@@ -169,18 +316,26 @@ func (r OpsBookingReadModel) createReadModel(
 		return err
 	}
 
-	_, err = r.db.ExecContext(ctx, `
+	res, err := r.db.ExecContext(ctx, `
 		INSERT INTO 
-		    read_model_ops_bookings (payload, booking_id)
+		    read_model_ops_bookings (payload, booking_id, customer_email, show_id, booked_at, has_refunds, printed, receipt_issue_dates, last_update)
 		VALUES
-			($1, $2)
+			($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (booking_id) DO NOTHING; -- read model may be already updated by another event - we don't want to override
-`, payload, booking.BookingID)
+`, append([]any{payload, booking.BookingID}, indexedColumns(booking)...)...)
 
 	if err != nil {
 		return fmt.Errorf("could not create read model: %w", err)
 	}
 
+	created, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if created > 0 {
+		r.publishReadModelUpdated(ctx, booking.BookingID)
+	}
+
 	return nil
 }
 
@@ -189,7 +344,7 @@ func (r OpsBookingReadModel) updateBookingReadModel(
 	bookingID string,
 	updateFunc func(ticket entity.OpsBooking) (entity.OpsBooking, error),
 ) (err error) {
-	return db.UpdateInTx(
+	err = db.UpdateInTx(
 		ctx,
 		r.db,
 		sql.LevelRepeatableRead,
@@ -207,21 +362,16 @@ func (r OpsBookingReadModel) updateBookingReadModel(
 				return err
 			}
 
-			err = r.updateReadModel(ctx, tx, updatedRm)
-			if err != nil {
-				return err
-			}
-
-			err = r.eventBus.Publish(ctx, entity.InternalOpsReadModelUpdated{
-				Header:    entity.NewEventHeader(),
-				BookingID: bookingID,
-			})
-			if err != nil {
-				log.FromContext(ctx).Errorf("could not publish event InternalOpsReadModelUpdated: %s", err)
-			}
-			return nil
+			return r.updateReadModel(ctx, tx, updatedRm)
 		},
 	)
+	if err != nil {
+		return err
+	}
+
+	r.publishReadModelUpdated(ctx, bookingID)
+
+	return nil
 }
 
 func (r OpsBookingReadModel) updateTicketInBookingReadModel(
@@ -229,7 +379,9 @@ func (r OpsBookingReadModel) updateTicketInBookingReadModel(
 	ticketID string,
 	updateFunc func(ticket entity.OpsTicket) (entity.OpsTicket, error),
 ) (err error) {
-	return db.UpdateInTx(
+	var bookingID string
+
+	err = db.UpdateInTx(
 		ctx,
 		r.db,
 		sql.LevelRepeatableRead,
@@ -250,10 +402,30 @@ func (r OpsBookingReadModel) updateTicketInBookingReadModel(
 			}
 
 			rm.Tickets[ticketID] = updatedRm
+			bookingID = rm.BookingID
 
 			return r.updateReadModel(ctx, tx, rm)
 		},
 	)
+	if err != nil {
+		return err
+	}
+
+	r.publishReadModelUpdated(ctx, bookingID)
+
+	return nil
+}
+
+// publishReadModelUpdated notifies about the update after the transaction is committed,
+// so subscribers always read the updated read model.
+func (r OpsBookingReadModel) publishReadModelUpdated(ctx context.Context, bookingID string) {
+	err := r.eventBus.Publish(ctx, entity.InternalOpsReadModelUpdated{
+		Header:    entity.NewEventHeader(),
+		BookingID: bookingID,
+	})
+	if err != nil {
+		log.FromContext(ctx).Errorf("could not publish event InternalOpsReadModelUpdated: %s", err)
+	}
 }
 
 func (r OpsBookingReadModel) updateReadModel(
@@ -261,7 +433,8 @@ func (r OpsBookingReadModel) updateReadModel(
 	tx *sqlx.Tx,
 	rm entity.OpsBooking,
 ) error {
-	rm.LastUpdate = time.Now()
+	// truncated to the precision stored by Postgres, so it can be used to resume the updates stream
+	rm.LastUpdate = time.Now().UTC().Truncate(time.Microsecond)
 
 	payload, err := json.Marshal(rm)
 	if err != nil {
@@ -270,11 +443,21 @@ func (r OpsBookingReadModel) updateReadModel(
 
 	_, err = tx.ExecContext(ctx, `
 		INSERT INTO 
-			read_model_ops_bookings (payload, booking_id)
+			read_model_ops_bookings (payload, booking_id, customer_email, show_id, booked_at, has_refunds, printed, receipt_issue_dates, last_update)
 		VALUES
-			($1, $2)
-		ON CONFLICT (booking_id) DO UPDATE SET payload = excluded.payload;
-		`, payload, rm.BookingID)
+			($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (booking_id) DO UPDATE SET
+			payload = excluded.payload,
+			customer_email = excluded.customer_email,
+			show_id = excluded.show_id,
+			booked_at = excluded.booked_at,
+			has_refunds = excluded.has_refunds,
+			printed = excluded.printed,
+			receipt_issue_dates = excluded.receipt_issue_dates,
+			last_update = excluded.last_update,
+			update_transaction_id = pg_current_xact_id(),
+			update_position = nextval('read_model_ops_bookings_update_position_seq');
+		`, append([]any{payload, rm.BookingID}, indexedColumns(rm)...)...)
 	if err != nil {
 		return fmt.Errorf("could not update read model: %w", err)
 	}
@@ -333,6 +516,21 @@ func (r OpsBookingReadModel) unmarshalReadModelFromDB(payload []byte) (entity.Op
 	return dbReadModel, nil
 }
 
+// indexedColumns returns values of the columns denormalized from the payload for filtering,
+// in order: customer_email, show_id, booked_at, has_refunds, printed, receipt_issue_dates, last_update.
+func indexedColumns(rm entity.OpsBooking) []any {
+	return []any{
+		sql.NullString{String: rm.CustomerEmail, Valid: rm.CustomerEmail != ""},
+		sql.NullString{String: rm.ShowID, Valid: rm.ShowID != ""},
+		// truncated to the precision stored by Postgres, so it matches pagination cursors
+		rm.BookedAt.Truncate(time.Microsecond),
+		rm.HasRefunds(),
+		rm.AllTicketsPrinted(),
+		pq.StringArray(rm.ReceiptIssueDates()),
+		rm.LastUpdate,
+	}
+}
+
 type dbExecutor interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
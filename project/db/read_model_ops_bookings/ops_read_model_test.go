@@ -0,0 +1,148 @@
+package read_model_ops_bookings
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbutils "tickets/db"
+	"tickets/entity"
+	"tickets/pubsub/bus"
+)
+
+func TestOpsBookingReadModel_AllReservations(t *testing.T) {
+	ctx := context.Background()
+	container, url := dbutils.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+
+	eventBus, err := bus.NewEventBus(gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{}))
+	require.NoError(t, err)
+
+	db := dbutils.GetDb(t)
+	rm := NewOpsBookingReadModel(db, eventBus)
+
+	customerEmail := uuid.NewString() + "@example.com"
+	showID := uuid.NewString()
+	createdSince, err := rm.UpdatesPosition(ctx)
+	require.NoError(t, err)
+
+	var bookingIDs []string
+	var printedTicketID string
+	for i := 0; i < 3; i++ {
+		bookingMade := entity.BookingMade_v1{
+			Header:          entity.NewEventHeader(),
+			BookingID:       uuid.NewString(),
+			NumberOfTickets: 1,
+			CustomerEmail:   customerEmail,
+			ShowID:          showID,
+		}
+		bookingMade.Header.PublishedAt = time.Now().UTC().Add(time.Duration(i) * time.Minute)
+		require.NoError(t, rm.OnBookingMade(ctx, &bookingMade))
+
+		ticketID := uuid.NewString()
+		require.NoError(t, rm.OnTicketBookingConfirmed(ctx, &entity.TicketBookingConfirmed_v1{
+			Header:        entity.NewEventHeader(),
+			TicketID:      ticketID,
+			CustomerEmail: customerEmail,
+			Price:         entity.Money{Amount: decimal.RequireFromString("10.00"), Currency: "EUR"},
+			BookingID:     bookingMade.BookingID,
+		}))
+
+		if i == 0 {
+			printedTicketID = ticketID
+			require.NoError(t, rm.OnTicketPrinted(ctx, &entity.TicketPrinted_v1{
+				Header:   entity.NewEventHeader(),
+				TicketID: ticketID,
+				FileName: ticketID + "-ticket.html",
+			}))
+		}
+
+		bookingIDs = append(bookingIDs, bookingMade.BookingID)
+	}
+
+	bookings, nextCursor, err := rm.AllReservations(ctx, entity.OpsBookingsFilter{
+		CustomerEmail: customerEmail,
+		Printed:       lo.ToPtr(true),
+	})
+	require.NoError(t, err)
+	require.Len(t, bookings, 1)
+	assert.Equal(t, bookingIDs[0], bookings[0].BookingID)
+	assert.Empty(t, nextCursor)
+
+	var pagedBookingIDs []string
+	filter := entity.OpsBookingsFilter{
+		ShowID: showID,
+		Sort:   entity.OpsBookingsSortBookedAtAsc,
+		Limit:  2,
+	}
+	for {
+		bookings, nextCursor, err := rm.AllReservations(ctx, filter)
+		require.NoError(t, err)
+
+		for _, booking := range bookings {
+			pagedBookingIDs = append(pagedBookingIDs, booking.BookingID)
+		}
+
+		if nextCursor == "" {
+			break
+		}
+		filter.Cursor = nextCursor
+	}
+	assert.Equal(t, bookingIDs, pagedBookingIDs)
+
+	printedTicket, err := rm.TicketReadModel(ctx, printedTicketID)
+	require.NoError(t, err)
+	assert.Equal(t, printedTicketID+"-ticket.html", printedTicket.PrintedFileName)
+
+	_, err = rm.TicketReadModel(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, entity.ErrNotFound)
+
+	updated, err := rm.UpdatedSince(ctx, createdSince, bookingIDs[1])
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Equal(t, bookingIDs[1], updated[0].Booking.BookingID)
+
+	updated, err = rm.UpdatedSince(ctx, updated[0].Position, bookingIDs[1])
+	require.NoError(t, err)
+	assert.Empty(t, updated)
+
+	_, err = rm.UpdatedSince(ctx, "invalid", "")
+	assert.ErrorIs(t, err, entity.ErrInvalidCursor)
+
+	// an update committed after a newer one, is returned after the newer one
+	since, err := rm.UpdatesPosition(ctx)
+	require.NoError(t, err)
+
+	olderTx, err := db.BeginTxx(ctx, nil)
+	require.NoError(t, err)
+	defer olderTx.Rollback()
+	_, err = olderTx.ExecContext(ctx, "SELECT pg_current_xact_id()")
+	require.NoError(t, err)
+
+	require.NoError(t, rm.OnTicketPrinted(ctx, &entity.TicketPrinted_v1{
+		Header:   entity.NewEventHeader(),
+		TicketID: printedTicketID,
+		FileName: printedTicketID + "-reprinted-ticket.html",
+	}))
+
+	updated, err = rm.UpdatedSince(ctx, since, bookingIDs[0])
+	require.NoError(t, err)
+	assert.Empty(t, updated, "update should wait until the older transaction is finished")
+
+	require.NoError(t, olderTx.Commit())
+
+	updated, err = rm.UpdatedSince(ctx, since, bookingIDs[0])
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Equal(t, bookingIDs[0], updated[0].Booking.BookingID)
+}
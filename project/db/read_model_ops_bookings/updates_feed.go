@@ -0,0 +1,60 @@
+package read_model_ops_bookings
+
+import (
+	"context"
+	"sync"
+
+	"tickets/entity"
+)
+
+// subscriberBufferSize is the number of pending updates kept per subscriber.
+// When a subscriber is not keeping up, newer updates are dropped for it,
+// so subscribers should query all updates since the last one they've seen.
+const subscriberBufferSize = 64
+
+// UpdatesFeed broadcasts IDs of updated bookings to all subscribers in this service instance.
+type UpdatesFeed struct {
+	lock        sync.Mutex
+	subscribers map[chan string]struct{}
+}
+
+func NewUpdatesFeed() *UpdatesFeed {
+	return &UpdatesFeed{
+		subscribers: make(map[chan string]struct{}),
+	}
+}
+
+// Subscribe returns a channel with IDs of updated bookings and a function that must be called to unsubscribe.
+func (f *UpdatesFeed) Subscribe() (<-chan string, func()) {
+	ch := make(chan string, subscriberBufferSize)
+
+	f.lock.Lock()
+	f.subscribers[ch] = struct{}{}
+	f.lock.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			f.lock.Lock()
+			delete(f.subscribers, ch)
+			f.lock.Unlock()
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+func (f *UpdatesFeed) OnReadModelUpdated(ctx context.Context, event *entity.InternalOpsReadModelUpdated) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for ch := range f.subscribers {
+		select {
+		case ch <- event.BookingID:
+		default:
+			// subscriber already has a pending update, it will read all missed updates with it
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package read_model_ops_bookings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tickets/entity"
+)
+
+// updatesPosition is the position of an update in the updates stream:
+// the ID of the transaction which wrote it, and its order within the transaction.
+type updatesPosition struct {
+	// TransactionID is XID8, an unsigned 64-bit integer, so it's kept as text
+	TransactionID string
+	Position      int64
+}
+
+func (p updatesPosition) String() string {
+	return p.TransactionID + "-" + strconv.FormatInt(p.Position, 10)
+}
+
+func parseUpdatesPosition(position string) (updatesPosition, error) {
+	transactionID, pos, ok := strings.Cut(position, "-")
+	if !ok {
+		return updatesPosition{}, entity.ErrInvalidCursor
+	}
+
+	if _, err := strconv.ParseUint(transactionID, 10, 64); err != nil {
+		return updatesPosition{}, fmt.Errorf("%w: %s", entity.ErrInvalidCursor, err)
+	}
+
+	p, err := strconv.ParseInt(pos, 10, 64)
+	if err != nil {
+		return updatesPosition{}, fmt.Errorf("%w: %s", entity.ErrInvalidCursor, err)
+	}
+
+	return updatesPosition{TransactionID: transactionID, Position: p}, nil
+}
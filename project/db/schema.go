@@ -10,12 +10,24 @@ func InitializeDatabaseSchema(db *sqlx.DB) error {
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS tickets (
 			ticket_id UUID PRIMARY KEY,
-			price_amount NUMERIC(10, 2) NOT NULL,
+			price_amount NUMERIC(12, 4) NOT NULL,
 			price_currency CHAR(3) NOT NULL,
 			customer_email VARCHAR(255) NOT NULL,
 			deleted_at TIMESTAMP
 		);
 
+		ALTER TABLE tickets ADD COLUMN IF NOT EXISTS booking_id UUID;
+		-- currencies have up to 4 minor units
+		ALTER TABLE tickets ALTER COLUMN price_amount TYPE NUMERIC(12, 4);
+
+		ALTER TABLE tickets
+			ADD COLUMN IF NOT EXISTS status VARCHAR(255) NOT NULL DEFAULT 'confirmed',
+			ADD COLUMN IF NOT EXISTS printed_at TIMESTAMPTZ,
+			ADD COLUMN IF NOT EXISTS receipt_issued_at TIMESTAMPTZ,
+			ADD COLUMN IF NOT EXISTS receipt_number VARCHAR(255) NOT NULL DEFAULT '',
+			ADD COLUMN IF NOT EXISTS refunded_at TIMESTAMPTZ;
+		CREATE INDEX IF NOT EXISTS tickets_booking_id_idx ON tickets (booking_id);
+
 		CREATE TABLE IF NOT EXISTS shows (
 			show_id UUID PRIMARY KEY,
 			dead_nation_id VARCHAR(255) NOT NULL,
@@ -25,6 +37,24 @@ func InitializeDatabaseSchema(db *sqlx.DB) error {
 			venue VARCHAR(255) NOT NULL
 		);
 
+		ALTER TABLE shows ADD COLUMN IF NOT EXISTS canceled_at TIMESTAMPTZ;
+
+		CREATE TABLE IF NOT EXISTS show_price_categories (
+			show_id UUID NOT NULL
+				REFERENCES shows(show_id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			-- the first category is the default one
+			position INT NOT NULL,
+			price_amount NUMERIC(12, 4) NOT NULL,
+			price_currency CHAR(3) NOT NULL,
+			PRIMARY KEY (show_id, name)
+		);
+
+		ALTER TABLE show_price_categories ALTER COLUMN price_amount TYPE NUMERIC(12, 4);
+
+		CREATE INDEX IF NOT EXISTS shows_start_time_idx ON shows (start_time, show_id);
+		CREATE INDEX IF NOT EXISTS shows_venue_idx ON shows (venue);
+
 		CREATE TABLE IF NOT EXISTS bookings (
 			booking_id UUID PRIMARY KEY,
 			show_id UUID NOT NULL
@@ -33,11 +63,126 @@ func InitializeDatabaseSchema(db *sqlx.DB) error {
 			number_of_tickets INT NOT NULL
 		);
 
+		ALTER TABLE bookings ADD COLUMN IF NOT EXISTS canceled_at TIMESTAMP;
+		ALTER TABLE bookings ADD COLUMN IF NOT EXISTS price_category VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE bookings ADD COLUMN IF NOT EXISTS quote JSONB;
+		ALTER TABLE bookings ADD COLUMN IF NOT EXISTS discount JSONB;
+
+		CREATE TABLE IF NOT EXISTS promotions (
+			code VARCHAR(255) PRIMARY KEY,
+			discount_type VARCHAR(255) NOT NULL,
+			-- percentage is set for percentage discounts, amount and currency for fixed ones
+			percentage NUMERIC(7, 4),
+			amount NUMERIC(12, 4),
+			currency CHAR(3),
+			-- promotions without a show are valid for all shows
+			show_id UUID
+				REFERENCES shows(show_id) ON DELETE CASCADE,
+			valid_from TIMESTAMPTZ,
+			valid_until TIMESTAMPTZ,
+			usage_limit INT,
+			used_count INT NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS seat_holds (
+			hold_id UUID PRIMARY KEY,
+			show_id UUID NOT NULL
+				REFERENCES shows(show_id) ON DELETE CASCADE,
+			number_of_tickets INT NOT NULL,
+			customer_email VARCHAR(255) NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			booking_id UUID,
+			confirmed_at TIMESTAMPTZ,
+			expired_at TIMESTAMPTZ
+		);
+
+		-- active holds, which are counted as booked seats until they are confirmed or expire
+		CREATE INDEX IF NOT EXISTS seat_holds_active_idx ON seat_holds (show_id, expires_at)
+			WHERE confirmed_at IS NULL AND expired_at IS NULL;
+
+		CREATE TABLE IF NOT EXISTS show_seats (
+			show_id UUID NOT NULL
+				REFERENCES shows(show_id) ON DELETE CASCADE,
+			section VARCHAR(255) NOT NULL,
+			seat_row VARCHAR(255) NOT NULL,
+			seat_number INT NOT NULL,
+			category VARCHAR(255) NOT NULL DEFAULT '',
+			booking_id UUID,
+			ticket_id UUID,
+			PRIMARY KEY (show_id, section, seat_row, seat_number)
+		);
+
+		CREATE INDEX IF NOT EXISTS show_seats_booking_id_idx ON show_seats (booking_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS show_seats_ticket_id_idx ON show_seats (ticket_id);
+
+		CREATE TABLE IF NOT EXISTS waitlist_entries (
+			entry_id UUID PRIMARY KEY,
+			show_id UUID NOT NULL
+				REFERENCES shows(show_id) ON DELETE CASCADE,
+			number_of_tickets INT NOT NULL,
+			customer_email VARCHAR(255) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			hold_id UUID
+				REFERENCES seat_holds(hold_id),
+			offered_at TIMESTAMPTZ
+		);
+
+		CREATE INDEX IF NOT EXISTS waitlist_entries_waiting_idx ON waitlist_entries (show_id, created_at)
+			WHERE hold_id IS NULL;
+
 		CREATE TABLE IF NOT EXISTS read_model_ops_bookings (
 			booking_id UUID PRIMARY KEY,
 			payload JSONB NOT NULL
 		);
 
+		-- columns denormalized from payload, so filtering doesn't need to scan JSONB
+		ALTER TABLE read_model_ops_bookings
+			ADD COLUMN IF NOT EXISTS customer_email VARCHAR(255),
+			ADD COLUMN IF NOT EXISTS show_id UUID,
+			ADD COLUMN IF NOT EXISTS booked_at TIMESTAMPTZ,
+			ADD COLUMN IF NOT EXISTS has_refunds BOOLEAN NOT NULL DEFAULT FALSE,
+			ADD COLUMN IF NOT EXISTS printed BOOLEAN NOT NULL DEFAULT FALSE,
+			ADD COLUMN IF NOT EXISTS receipt_issue_dates DATE[] NOT NULL DEFAULT '{}',
+			ADD COLUMN IF NOT EXISTS last_update TIMESTAMPTZ;
+
+		UPDATE read_model_ops_bookings SET
+			customer_email = COALESCE(
+				NULLIF(payload->>'customer_email', ''),
+				jsonb_path_query_first(payload, '$.tickets.*.customer_email') #>> '{}'
+			),
+			show_id = NULLIF(payload->>'show_id', '')::UUID,
+			booked_at = (payload->>'booked_at')::TIMESTAMPTZ,
+			has_refunds = jsonb_path_exists(payload, '$.tickets.* ? (@.refunded_at != "0001-01-01T00:00:00Z")'),
+			printed = payload->'tickets' IS NOT NULL
+				AND payload->'tickets' != '{}'::JSONB
+				AND NOT jsonb_path_exists(payload, '$.tickets.* ? (@.printed_at == "0001-01-01T00:00:00Z")'),
+			receipt_issue_dates = ARRAY(
+				SELECT DISTINCT ((issued_at #>> '{}')::TIMESTAMPTZ AT TIME ZONE 'UTC')::DATE
+				FROM jsonb_path_query(payload, '$.tickets.*.receipt_issued_at') AS issued_at
+				WHERE issued_at #>> '{}' != '0001-01-01T00:00:00Z'
+			)
+		WHERE booked_at IS NULL;
+
+		UPDATE read_model_ops_bookings SET last_update = (payload->>'last_update')::TIMESTAMPTZ
+		WHERE last_update IS NULL;
+
+		-- position of the last update in the updates stream, ordered by the transaction which wrote it,
+		-- so updates committed by slower transactions are not skipped by resumed streams
+		CREATE SEQUENCE IF NOT EXISTS read_model_ops_bookings_update_position_seq;
+
+		ALTER TABLE read_model_ops_bookings
+			ADD COLUMN IF NOT EXISTS update_transaction_id XID8 NOT NULL DEFAULT pg_current_xact_id(),
+			ADD COLUMN IF NOT EXISTS update_position BIGINT NOT NULL DEFAULT nextval('read_model_ops_bookings_update_position_seq');
+
+		CREATE INDEX IF NOT EXISTS read_model_ops_bookings_booked_at_idx ON read_model_ops_bookings (booked_at, booking_id);
+		CREATE INDEX IF NOT EXISTS read_model_ops_bookings_customer_email_idx ON read_model_ops_bookings (customer_email);
+		CREATE INDEX IF NOT EXISTS read_model_ops_bookings_show_id_idx ON read_model_ops_bookings (show_id);
+		CREATE INDEX IF NOT EXISTS read_model_ops_bookings_has_refunds_idx ON read_model_ops_bookings (has_refunds);
+		CREATE INDEX IF NOT EXISTS read_model_ops_bookings_printed_idx ON read_model_ops_bookings (printed);
+		DROP INDEX IF EXISTS read_model_ops_bookings_last_update_idx;
+		CREATE INDEX IF NOT EXISTS read_model_ops_bookings_update_position_idx ON read_model_ops_bookings (update_transaction_id, update_position);
+		CREATE INDEX IF NOT EXISTS read_model_ops_bookings_receipt_issue_dates_idx ON read_model_ops_bookings USING GIN (receipt_issue_dates);
+
 		CREATE TABLE IF NOT EXISTS events (
 			event_id UUID PRIMARY KEY,
 			published_at TIMESTAMP NOT NULL,
@@ -45,6 +190,68 @@ func InitializeDatabaseSchema(db *sqlx.DB) error {
 			event_payload JSONB NOT NULL
 		);
 
+		ALTER TABLE events
+			ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255) NOT NULL DEFAULT '',
+			ADD COLUMN IF NOT EXISTS causation_id VARCHAR(255) NOT NULL DEFAULT '';
+		CREATE INDEX IF NOT EXISTS events_correlation_id_idx ON events (correlation_id);
+
+		CREATE TABLE IF NOT EXISTS http_idempotency_keys (
+			idempotency_key VARCHAR(255) PRIMARY KEY,
+			request_hash VARCHAR(64) NOT NULL,
+			response_status INT,
+			response_content_type VARCHAR(255),
+			response_body BYTEA,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS scheduled_messages (
+			message_uuid VARCHAR(255) PRIMARY KEY,
+			topic VARCHAR(255) NOT NULL,
+			metadata JSONB NOT NULL,
+			payload BYTEA NOT NULL,
+			publish_at TIMESTAMPTZ NOT NULL,
+			published_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS scheduled_messages_due_idx ON scheduled_messages (publish_at) WHERE published_at IS NULL;
+		-- messages are deleted once published, published_at is set only on messages published before
+		DELETE FROM scheduled_messages WHERE published_at IS NOT NULL;
+
+		-- Dead Nation API doesn't support booking cancellation, bookings are canceled there manually
+		CREATE TABLE IF NOT EXISTS dead_nation_manual_cancellations (
+			booking_id UUID PRIMARY KEY,
+			requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			canceled_at TIMESTAMPTZ
+		);
+
+		CREATE TABLE IF NOT EXISTS processed_commands (
+			command_id VARCHAR(255) NOT NULL,
+			handler_name VARCHAR(255) NOT NULL,
+			completed_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (command_id, handler_name)
+		);
+
+		CREATE TABLE IF NOT EXISTS ticket_refunds (
+			ticket_id UUID PRIMARY KEY,
+			status VARCHAR(16) NOT NULL,
+			failure_reason TEXT NOT NULL DEFAULT '',
+			requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			completed_at TIMESTAMPTZ
+		);
+
+		-- final statuses of tickets stored before tickets had a status
+		UPDATE tickets SET status = 'canceled'
+		WHERE status = 'confirmed' AND deleted_at IS NOT NULL;
+
+		UPDATE tickets t SET status = 'refunded', refunded_at = r.completed_at
+		FROM ticket_refunds r
+		WHERE r.ticket_id = t.ticket_id AND t.status = 'confirmed' AND r.status = 'completed';
+
+		CREATE TABLE IF NOT EXISTS show_cancellations (
+			show_id UUID PRIMARY KEY,
+			payload JSONB NOT NULL
+		);
+
 		CREATE TABLE IF NOT EXISTS vip_bundles (
 			vip_bundle_id UUID PRIMARY KEY,
 			booking_id UUID NOT NULL UNIQUE,
@@ -0,0 +1,167 @@
+package show_cancellations
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"tickets/db"
+	"tickets/entity"
+)
+
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	if db == nil {
+		panic("db must be set")
+	}
+
+	return &PostgresRepository{db: db}
+}
+
+func (r PostgresRepository) Add(ctx context.Context, showCancellation entity.ShowCancellation) (entity.ShowCancellation, error) {
+	payload, err := json.Marshal(showCancellation)
+	if err != nil {
+		return entity.ShowCancellation{}, fmt.Errorf("could not marshal show cancellation: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO show_cancellations (show_id, payload)
+		VALUES ($1, $2)
+		ON CONFLICT (show_id) DO NOTHING
+	`, showCancellation.ShowID, payload)
+	if err != nil {
+		return entity.ShowCancellation{}, fmt.Errorf("could not insert show cancellation: %w", err)
+	}
+
+	return r.Get(ctx, showCancellation.ShowID)
+}
+
+func (r PostgresRepository) Get(ctx context.Context, showID string) (entity.ShowCancellation, error) {
+	return r.getShowCancellation(ctx, r.db, `
+		SELECT payload FROM show_cancellations WHERE show_id = $1
+	`, showID)
+}
+
+func (r PostgresRepository) UpdateByTicketID(
+	ctx context.Context,
+	ticketID string,
+	updateFn func(showCancellation entity.ShowCancellation) (entity.ShowCancellation, error),
+) (entity.ShowCancellation, error) {
+	return r.update(ctx, updateFn, func(ctx context.Context, tx *sqlx.Tx) (entity.ShowCancellation, error) {
+		return r.getShowCancellation(ctx, tx, `
+			SELECT sc.payload
+			FROM show_cancellations sc
+			JOIN bookings b ON b.show_id = sc.show_id
+			JOIN tickets t ON t.booking_id = b.booking_id
+			WHERE t.ticket_id = $1
+			FOR UPDATE OF sc
+		`, ticketID)
+	})
+}
+
+func (r PostgresRepository) UpdateByBookingID(
+	ctx context.Context,
+	bookingID string,
+	updateFn func(showCancellation entity.ShowCancellation) (entity.ShowCancellation, error),
+) (entity.ShowCancellation, error) {
+	if _, err := uuid.Parse(bookingID); err != nil {
+		// tickets booked outside of the service don't have bookings
+		return entity.ShowCancellation{}, entity.ErrNotFound
+	}
+
+	return r.update(ctx, updateFn, func(ctx context.Context, tx *sqlx.Tx) (entity.ShowCancellation, error) {
+		var showID string
+		var cancellationCreated bool
+		err := tx.QueryRowxContext(ctx, `
+			SELECT b.show_id, sc.show_id IS NOT NULL
+			FROM bookings b
+			JOIN shows s ON s.show_id = b.show_id
+			LEFT JOIN show_cancellations sc ON sc.show_id = b.show_id
+			WHERE b.booking_id = $1 AND s.canceled_at IS NOT NULL
+		`, bookingID).Scan(&showID, &cancellationCreated)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.ShowCancellation{}, entity.ErrNotFound
+		}
+		if err != nil {
+			return entity.ShowCancellation{}, fmt.Errorf("could not get show of booking %s: %w", bookingID, err)
+		}
+		if !cancellationCreated {
+			// the cancellation is created asynchronously, it should spin until it's created
+			return entity.ShowCancellation{}, fmt.Errorf("cancellation of show %s not exist yet", showID)
+		}
+
+		return r.getShowCancellation(ctx, tx, `
+			SELECT payload FROM show_cancellations WHERE show_id = $1 FOR UPDATE
+		`, showID)
+	})
+}
+
+func (r PostgresRepository) update(
+	ctx context.Context,
+	updateFn func(showCancellation entity.ShowCancellation) (entity.ShowCancellation, error),
+	getFn func(ctx context.Context, tx *sqlx.Tx) (entity.ShowCancellation, error),
+) (entity.ShowCancellation, error) {
+	var sc entity.ShowCancellation
+	err := db.UpdateInTx(ctx, r.db, sql.LevelRepeatableRead, func(ctx context.Context, tx *sqlx.Tx) error {
+		var err error
+		sc, err = getFn(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		sc, err = updateFn(sc)
+		if err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(sc)
+		if err != nil {
+			return fmt.Errorf("could not marshal show cancellation: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE show_cancellations SET payload = $1 WHERE show_id = $2
+		`, payload, sc.ShowID)
+		if err != nil {
+			return fmt.Errorf("could not update show cancellation: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return entity.ShowCancellation{}, fmt.Errorf("could not update show cancellation: %w", err)
+	}
+
+	return sc, nil
+}
+
+func (r PostgresRepository) getShowCancellation(
+	ctx context.Context,
+	q sqlx.QueryerContext,
+	query string,
+	args ...any,
+) (entity.ShowCancellation, error) {
+	var payload []byte
+	err := q.QueryRowxContext(ctx, query, args...).Scan(&payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.ShowCancellation{}, entity.ErrNotFound
+	}
+	if err != nil {
+		return entity.ShowCancellation{}, fmt.Errorf("could not get show cancellation: %w", err)
+	}
+
+	var sc entity.ShowCancellation
+	if err := json.Unmarshal(payload, &sc); err != nil {
+		return entity.ShowCancellation{}, fmt.Errorf("could not unmarshal show cancellation: %w", err)
+	}
+
+	return sc, nil
+}
@@ -0,0 +1,75 @@
+package shows
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"tickets/db"
+	"tickets/entity"
+)
+
+type priceCategoryRow struct {
+	ShowID string `db:"show_id"`
+	Name   string `db:"name"`
+	db.PriceColumns
+}
+
+// storePriceCategories replaces price categories of the show, keeping their order.
+func storePriceCategories(ctx context.Context, tx *sqlx.Tx, showID string, categories []entity.PriceCategory) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM show_price_categories WHERE show_id = $1`, showID)
+	if err != nil {
+		return fmt.Errorf("could not delete price categories: %w", err)
+	}
+
+	for position, category := range categories {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO show_price_categories (show_id, name, position, price_amount, price_currency)
+			VALUES ($1, $2, $3, $4, $5)
+		`, showID, category.Name, position, category.Price.Amount, category.Price.Currency)
+		if err != nil {
+			return fmt.Errorf("could not add price category %s: %w", category.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// loadPriceCategories sets price categories of the shows.
+func loadPriceCategories(ctx context.Context, q sqlx.QueryerContext, shows []entity.Show) error {
+	if len(shows) == 0 {
+		return nil
+	}
+
+	showIDs := make([]string, 0, len(shows))
+	for _, show := range shows {
+		showIDs = append(showIDs, show.ShowID)
+	}
+
+	var rows []priceCategoryRow
+	err := sqlx.SelectContext(ctx, q, &rows, `
+		SELECT show_id, name, price_amount, price_currency
+		FROM show_price_categories
+		WHERE show_id = ANY($1)
+		ORDER BY show_id, position
+	`, pq.Array(showIDs))
+	if err != nil {
+		return fmt.Errorf("could not get price categories: %w", err)
+	}
+
+	categories := make(map[string][]entity.PriceCategory, len(shows))
+	for _, row := range rows {
+		categories[row.ShowID] = append(categories[row.ShowID], entity.PriceCategory{
+			Name:  row.Name,
+			Price: row.Money(),
+		})
+	}
+
+	for i := range shows {
+		shows[i].PriceCategories = categories[shows[i].ShowID]
+	}
+
+	return nil
+}
@@ -2,10 +2,22 @@ package shows
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 
+	"tickets/db"
 	"tickets/entity"
+	"tickets/pubsub/outbox"
+)
+
+const (
+	defaultListLimit = 20
+
+	showColumns = "show_id, dead_nation_id, number_of_tickets, start_time, title, venue, canceled_at"
 )
 
 type PostgresRepository struct {
@@ -17,21 +29,287 @@ func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
 }
 
 func (r *PostgresRepository) Store(ctx context.Context, show entity.Show) error {
-	_, err := r.db.NamedExecContext(ctx, `
-		INSERT INTO shows (show_id, dead_nation_id, number_of_tickets, start_time, title, venue)
-		VALUES (:show_id, :dead_nation_id, :number_of_tickets, :start_time, :title, :venue)
-		ON CONFLICT DO NOTHING -- ignore if already exists
-	`, show)
-	return err
+	return db.UpdateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			res, err := tx.NamedExecContext(ctx, `
+				INSERT INTO shows (show_id, dead_nation_id, number_of_tickets, start_time, title, venue)
+				VALUES (:show_id, :dead_nation_id, :number_of_tickets, :start_time, :title, :venue)
+				ON CONFLICT DO NOTHING -- ignore if already exists
+			`, show)
+			if err != nil {
+				return err
+			}
+
+			inserted, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if inserted == 0 {
+				return nil
+			}
+
+			return storePriceCategories(ctx, tx, show.ShowID, show.PriceCategories)
+		},
+	)
 }
 
 func (r *PostgresRepository) Get(ctx context.Context, showID string) (entity.Show, error) {
+	return r.getShow(ctx, r.db, showID, false)
+}
+
+func (r *PostgresRepository) getShow(ctx context.Context, q sqlx.QueryerContext, showID string, forUpdate bool) (entity.Show, error) {
+	query := "SELECT " + showColumns + " FROM shows WHERE show_id = $1"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var show entity.Show
+	err := sqlx.GetContext(ctx, q, &show, query, showID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Show{}, entity.ErrNotFound
+	}
+	if err != nil {
+		return entity.Show{}, err
+	}
+
+	shows := []entity.Show{show}
+	if err := loadPriceCategories(ctx, q, shows); err != nil {
+		return entity.Show{}, err
+	}
+
+	return shows[0], nil
+}
+
+// Update changes the show and publishes ShowUpdated_v1.
+// The number of tickets can't be lower than the number of booked and held seats.
+func (r *PostgresRepository) Update(ctx context.Context, showID string, update entity.ShowUpdate) (entity.Show, error) {
 	var show entity.Show
-	err := r.db.GetContext(ctx, &show, `
-		SELECT show_id, dead_nation_id, number_of_tickets, start_time, title, venue
-		FROM shows
-		WHERE show_id = $1
-	`, showID)
 
-	return show, err
+	err := db.UpdateInTx(
+		ctx,
+		r.db,
+		// bookings lock the show's row too, so seats booked while waiting for the lock
+		// must be visible to the check below
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			var err error
+			show, err = r.getShow(ctx, tx, showID, true)
+			if err != nil {
+				return err
+			}
+
+			if show.CanceledAt != nil {
+				return entity.ErrShowCanceled
+			}
+
+			if update.NumberOfTickets != nil && *update.NumberOfTickets != show.NumberOfTickets {
+				var hasSeatMap bool
+				err = tx.GetContext(ctx, &hasSeatMap, `
+					SELECT EXISTS (SELECT 1 FROM show_seats WHERE show_id = $1)
+				`, showID)
+				if err != nil {
+					return fmt.Errorf("could not check seat map: %w", err)
+				}
+				if hasSeatMap {
+					return entity.ErrCapacityDefinedBySeatMap
+				}
+
+				// held seats are booked without checking the capacity again, seats of refunded tickets are free,
+				// the same as when bookings check available tickets
+				bookedTickets, err := db.BookedTickets(ctx, tx, []string{showID})
+				if err != nil {
+					return fmt.Errorf("could not get booked seats: %w", err)
+				}
+				bookedSeats := bookedTickets[showID]
+
+				if *update.NumberOfTickets < bookedSeats {
+					return fmt.Errorf("%w: %d seats are booked", entity.ErrCapacityBelowBookedSeats, bookedSeats)
+				}
+
+				show.NumberOfTickets = *update.NumberOfTickets
+			}
+			if update.StartTime != nil {
+				show.StartTime = *update.StartTime
+			}
+			if update.Venue != nil {
+				show.Venue = *update.Venue
+			}
+			if update.PriceCategories != nil {
+				// existing bookings keep their quotes
+				show.PriceCategories = *update.PriceCategories
+
+				err = storePriceCategories(ctx, tx, showID, show.PriceCategories)
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err = tx.NamedExecContext(ctx, `
+				UPDATE shows
+				SET number_of_tickets = :number_of_tickets, start_time = :start_time, venue = :venue
+				WHERE show_id = :show_id
+			`, show)
+			if err != nil {
+				return fmt.Errorf("could not update show: %w", err)
+			}
+
+			eventBus, err := outbox.NewEventBusForTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			err = eventBus.Publish(ctx, entity.ShowUpdated_v1{
+				Header:          entity.NewEventHeader(),
+				ShowID:          show.ShowID,
+				NumberOfTickets: show.NumberOfTickets,
+				StartTime:       show.StartTime,
+				Venue:           show.Venue,
+				PriceCategories: show.PriceCategories,
+			})
+			if err != nil {
+				return fmt.Errorf("could not publish event: %w", err)
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return entity.Show{}, err
+	}
+
+	return show, nil
+}
+
+// Cancel marks the show as canceled and publishes ShowCanceled_v1, which starts refunding its tickets.
+// Open seat holds of the show are expired. Canceling an already canceled show does nothing.
+func (r *PostgresRepository) Cancel(ctx context.Context, showID string) error {
+	return db.UpdateInTx(
+		ctx,
+		r.db,
+		// holds are confirmed under the show's lock too, so holds confirmed while waiting for the lock
+		// must not be expired below
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			show, err := r.getShow(ctx, tx, showID, true)
+			if err != nil {
+				return err
+			}
+
+			if show.CanceledAt != nil {
+				return nil
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				UPDATE shows SET canceled_at = NOW() WHERE show_id = $1
+			`, showID)
+			if err != nil {
+				return fmt.Errorf("could not cancel show: %w", err)
+			}
+
+			// open holds and waitlist offers can't be confirmed anymore
+			var expiredHolds []entity.SeatHold
+			err = tx.SelectContext(ctx, &expiredHolds, `
+				UPDATE seat_holds SET expired_at = NOW()
+				WHERE show_id = $1 AND confirmed_at IS NULL AND expired_at IS NULL
+				RETURNING hold_id, show_id, number_of_tickets
+			`, showID)
+			if err != nil {
+				return fmt.Errorf("could not expire seat holds: %w", err)
+			}
+
+			eventBus, err := outbox.NewEventBusForTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			for _, hold := range expiredHolds {
+				err = eventBus.Publish(ctx, entity.SeatHoldExpired_v1{
+					Header:          entity.NewEventHeader(),
+					HoldID:          hold.HoldID,
+					ShowID:          hold.ShowID,
+					NumberOfTickets: hold.NumberOfTickets,
+				})
+				if err != nil {
+					return fmt.Errorf("could not publish event: %w", err)
+				}
+			}
+
+			err = eventBus.Publish(ctx, entity.ShowCanceled_v1{
+				Header: entity.NewEventHeader(),
+				ShowID: showID,
+			})
+			if err != nil {
+				return fmt.Errorf("could not publish event: %w", err)
+			}
+
+			return nil
+		},
+	)
+}
+
+// List returns shows ordered by start time, paginated with a keyset cursor.
+// The returned cursor is empty when there are no more shows to fetch.
+func (r *PostgresRepository) List(ctx context.Context, filter entity.ShowsFilter) ([]entity.Show, string, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Venue != "" {
+		addCondition("venue = $%d", filter.Venue)
+	}
+	if filter.Title != "" {
+		addCondition("title ILIKE '%%' || $%d || '%%'", filter.Title)
+	}
+	if filter.StartTimeFrom != nil {
+		addCondition("start_time >= $%d", *filter.StartTimeFrom)
+	}
+	if filter.StartTimeTo != nil {
+		addCondition("start_time <= $%d", *filter.StartTimeTo)
+	}
+	if filter.Cursor != "" {
+		startTime, showID, err := db.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		args = append(args, startTime, showID)
+		conditions = append(conditions, fmt.Sprintf("(start_time, show_id) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	query := "SELECT " + showColumns + " FROM shows"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// fetching one more row to know if there is a next page
+	query += fmt.Sprintf(" ORDER BY start_time, show_id LIMIT %d", limit+1)
+
+	var shows []entity.Show
+	if err := r.db.SelectContext(ctx, &shows, query, args...); err != nil {
+		return nil, "", fmt.Errorf("could not list shows: %w", err)
+	}
+
+	var nextCursor string
+	if len(shows) > limit {
+		shows = shows[:limit]
+		last := shows[len(shows)-1]
+		nextCursor = db.EncodeCursor(last.StartTime, last.ShowID)
+	}
+
+	if err := loadPriceCategories(ctx, r.db, shows); err != nil {
+		return nil, "", err
+	}
+
+	return shows, nextCursor, nil
 }
@@ -0,0 +1,144 @@
+package ticket_refunds
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"tickets/db"
+	"tickets/entity"
+	"tickets/pubsub/outbox"
+)
+
+const ticketRefundColumns = "ticket_id, status, failure_reason, requested_at, completed_at"
+
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	if db == nil {
+		panic("db must be set")
+	}
+
+	return &PostgresRepository{db: db}
+}
+
+// Request creates a pending refund of the ticket, and sends the RefundTicket command in the same transaction,
+// so a requested refund is always processed.
+// Failed refunds are requested again, pending and completed refunds are returned as they are.
+// The returned bool is true when the refund was requested by this call.
+func (r *PostgresRepository) Request(ctx context.Context, ticketID string) (entity.TicketRefund, bool, error) {
+	var refund entity.TicketRefund
+	var requested bool
+
+	err := db.UpdateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			err := tx.GetContext(ctx, &refund, `
+				INSERT INTO ticket_refunds (ticket_id, status)
+				VALUES ($1, $2)
+				ON CONFLICT (ticket_id) DO UPDATE SET
+					status = excluded.status,
+					failure_reason = '',
+					requested_at = NOW()
+				WHERE ticket_refunds.status = $3
+				RETURNING `+ticketRefundColumns,
+				ticketID,
+				entity.TicketRefundStatusPending,
+				entity.TicketRefundStatusFailed,
+			)
+			if errors.Is(err, sql.ErrNoRows) {
+				// refund is already pending or completed
+				refund, err = r.get(ctx, tx, ticketID)
+				return err
+			}
+			if err != nil {
+				return fmt.Errorf("could not request refund: %w", err)
+			}
+
+			commandBus, err := outbox.NewCommandBusForTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			err = commandBus.Send(ctx, &entity.RefundTicket{
+				Header:   entity.NewCommandHeaderWithIdempotencyKey(entity.TicketRefundIdempotencyKey(ticketID)),
+				TicketID: ticketID,
+			})
+			if err != nil {
+				return fmt.Errorf("could not send refund: %w", err)
+			}
+
+			requested = true
+
+			return nil
+		},
+	)
+	if err != nil {
+		return entity.TicketRefund{}, false, err
+	}
+
+	return refund, requested, nil
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, ticketID string) (entity.TicketRefund, error) {
+	return r.get(ctx, r.db, ticketID)
+}
+
+func (r *PostgresRepository) get(ctx context.Context, q sqlx.QueryerContext, ticketID string) (entity.TicketRefund, error) {
+	var refund entity.TicketRefund
+	err := sqlx.GetContext(ctx, q, &refund, `
+		SELECT `+ticketRefundColumns+`
+		FROM ticket_refunds
+		WHERE ticket_id = $1
+	`, ticketID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.TicketRefund{}, entity.ErrNotFound
+	}
+	if err != nil {
+		return entity.TicketRefund{}, fmt.Errorf("could not get refund: %w", err)
+	}
+
+	return refund, nil
+}
+
+// MarkFailed records the failure of the refund, unless it's already completed.
+// Refunds which were not requested through Request (for example, of canceled bookings) are tracked too.
+func (r *PostgresRepository) MarkFailed(ctx context.Context, ticketID string, failureReason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO ticket_refunds (ticket_id, status, failure_reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (ticket_id) DO UPDATE SET
+			status = excluded.status,
+			failure_reason = excluded.failure_reason
+		WHERE ticket_refunds.status <> $4
+	`, ticketID, entity.TicketRefundStatusFailed, failureReason, entity.TicketRefundStatusCompleted)
+	if err != nil {
+		return fmt.Errorf("could not mark refund as failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) OnTicketRefunded(ctx context.Context, event *entity.TicketRefunded_v1) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO ticket_refunds (ticket_id, status, completed_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (ticket_id) DO UPDATE SET
+			status = excluded.status,
+			failure_reason = '',
+			completed_at = excluded.completed_at
+		WHERE ticket_refunds.status <> excluded.status
+	`, event.TicketID, entity.TicketRefundStatusCompleted, event.Header.PublishedAt)
+	if err != nil {
+		return fmt.Errorf("could not mark refund as completed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package ticket_refunds
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbutils "tickets/db"
+	"tickets/entity"
+)
+
+func TestPostgresRepository(t *testing.T) {
+	ctx := context.Background()
+	container, url := dbutils.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+	repo := NewPostgresRepository(dbutils.GetDb(t))
+
+	t.Run("requested_once", func(t *testing.T) {
+		ticketID := uuid.NewString()
+
+		_, err := repo.Get(ctx, ticketID)
+		assert.ErrorIs(t, err, entity.ErrNotFound)
+
+		refund, requested, err := repo.Request(ctx, ticketID)
+		require.NoError(t, err)
+		assert.True(t, requested)
+		assert.Equal(t, entity.TicketRefundStatusPending, refund.Status)
+
+		refund, requested, err = repo.Request(ctx, ticketID)
+		require.NoError(t, err)
+		assert.False(t, requested, "pending refund should not be requested again")
+		assert.Equal(t, entity.TicketRefundStatusPending, refund.Status)
+	})
+
+	t.Run("failed_refund_is_requested_again", func(t *testing.T) {
+		ticketID := uuid.NewString()
+
+		_, _, err := repo.Request(ctx, ticketID)
+		require.NoError(t, err)
+
+		err = repo.MarkFailed(ctx, ticketID, "payments unavailable")
+		require.NoError(t, err)
+
+		refund, err := repo.Get(ctx, ticketID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.TicketRefundStatusFailed, refund.Status)
+		assert.Equal(t, "payments unavailable", refund.FailureReason)
+
+		refund, requested, err := repo.Request(ctx, ticketID)
+		require.NoError(t, err)
+		assert.True(t, requested)
+		assert.Equal(t, entity.TicketRefundStatusPending, refund.Status)
+		assert.Empty(t, refund.FailureReason)
+	})
+
+	t.Run("completed", func(t *testing.T) {
+		ticketID := uuid.NewString()
+
+		_, _, err := repo.Request(ctx, ticketID)
+		require.NoError(t, err)
+
+		header := entity.NewEventHeader()
+		header.PublishedAt = time.Now().UTC().Truncate(time.Microsecond)
+		err = repo.OnTicketRefunded(ctx, &entity.TicketRefunded_v1{Header: header, TicketID: ticketID})
+		require.NoError(t, err)
+
+		// failure of a re-delivered command should not override the completed refund
+		err = repo.MarkFailed(ctx, ticketID, "payments unavailable")
+		require.NoError(t, err)
+
+		refund, requested, err := repo.Request(ctx, ticketID)
+		require.NoError(t, err)
+		assert.False(t, requested)
+		assert.Equal(t, entity.TicketRefundStatusCompleted, refund.Status)
+		require.NotNil(t, refund.CompletedAt)
+		assert.True(t, header.PublishedAt.Equal(*refund.CompletedAt))
+	})
+}
@@ -2,13 +2,45 @@ package tickets
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
 
+	"tickets/db"
 	"tickets/entity"
 )
 
+const ticketColumns = "ticket_id, COALESCE(booking_id::text, '') AS booking_id, price_amount, price_currency, customer_email, " +
+	"status, printed_at, receipt_issued_at, receipt_number, refunded_at, deleted_at"
+
+type ticketRow struct {
+	entity.Ticket
+	db.PriceColumns
+}
+
+func newTicketRow(ticket entity.Ticket) ticketRow {
+	return ticketRow{
+		Ticket:       ticket,
+		PriceColumns: db.NewPriceColumns(ticket.Price),
+	}
+}
+
+func (r ticketRow) ticket() entity.Ticket {
+	ticket := r.Ticket
+	ticket.Price = r.Money()
+	return ticket
+}
+
+func ticketsFromRows(rows []ticketRow) []entity.Ticket {
+	tickets := make([]entity.Ticket, 0, len(rows))
+	for _, row := range rows {
+		tickets = append(tickets, row.ticket())
+	}
+	return tickets
+}
+
 type PostgresRepository struct {
 	db *sqlx.DB
 }
@@ -19,42 +51,123 @@ func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
 
 func (r *PostgresRepository) Store(ctx context.Context, ticket entity.Ticket) error {
 	_, err := r.db.NamedExecContext(ctx, `
-		INSERT INTO tickets (ticket_id, price_amount, price_currency, customer_email)
-		VALUES (:ticket_id, :price_amount, :price_currency, :customer_email)
+		INSERT INTO tickets (ticket_id, booking_id, price_amount, price_currency, customer_email, status)
+		VALUES (:ticket_id, CAST(NULLIF(:booking_id, '') AS UUID), :price_amount, :price_currency, :customer_email, :status)
 		ON CONFLICT DO NOTHING -- ignore if already exists
-	`, ticket)
+	`, newTicketRow(ticket))
 	return err
 }
 
-func (r *PostgresRepository) Delete(ctx context.Context, ticketID string) error {
-	res, err := r.db.ExecContext(ctx, `
-		UPDATE tickets
-		SET deleted_at = NOW()
-		WHERE ticket_id = $1
-	`, ticketID)
+// Update changes the ticket's state with updateFn, the ticket is locked until it's updated.
+// The ticket is not updated when updateFn returns an error.
+func (r *PostgresRepository) Update(
+	ctx context.Context,
+	ticketID string,
+	updateFn func(ticket entity.Ticket) (entity.Ticket, error),
+) (entity.Ticket, error) {
+	var ticket entity.Ticket
+
+	err := db.UpdateInTx(ctx, r.db, sql.LevelReadCommitted, func(ctx context.Context, tx *sqlx.Tx) error {
+		var err error
+		ticket, err = r.getTicket(ctx, tx, ticketID, true)
+		if err != nil {
+			return err
+		}
+
+		ticket, err = updateFn(ticket)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NamedExecContext(ctx, `
+			UPDATE tickets SET
+				status = :status,
+				printed_at = :printed_at,
+				receipt_issued_at = :receipt_issued_at,
+				receipt_number = :receipt_number,
+				refunded_at = :refunded_at,
+				deleted_at = :deleted_at
+			WHERE ticket_id = :ticket_id
+		`, newTicketRow(ticket))
+		if err != nil {
+			return fmt.Errorf("could not update ticket: %w", err)
+		}
 
+		return nil
+	})
 	if err != nil {
-		return err
+		return entity.Ticket{}, err
 	}
 
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		return err
+	return ticket, nil
+}
+
+// Get returns the ticket, including canceled ones.
+func (r *PostgresRepository) Get(ctx context.Context, ticketID string) (entity.Ticket, error) {
+	return r.getTicket(ctx, r.db, ticketID, false)
+}
+
+func (r *PostgresRepository) getTicket(ctx context.Context, q sqlx.QueryerContext, ticketID string, forUpdate bool) (entity.Ticket, error) {
+	query := "SELECT " + ticketColumns + " FROM tickets WHERE ticket_id = $1"
+	if forUpdate {
+		query += " FOR UPDATE"
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("ticket with ID %s not found", ticketID)
+	var row ticketRow
+	err := sqlx.GetContext(ctx, q, &row, query, ticketID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Ticket{}, entity.ErrNotFound
+	}
+	if err != nil {
+		return entity.Ticket{}, fmt.Errorf("could not get ticket: %w", err)
 	}
 
-	return nil
+	return row.ticket(), nil
 }
 
 func (r *PostgresRepository) FindAll(ctx context.Context) ([]entity.Ticket, error) {
-	var tickets []entity.Ticket
-	err := r.db.SelectContext(ctx, &tickets, `
-		SELECT ticket_id, price_amount, price_currency, customer_email
+	var rows []ticketRow
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT `+ticketColumns+`
 		FROM tickets
 		WHERE deleted_at IS NULL
 	`)
-	return tickets, err
+	if err != nil {
+		return nil, err
+	}
+
+	return ticketsFromRows(rows), nil
+}
+
+// FindByBookingID returns not canceled tickets of the booking.
+func (r *PostgresRepository) FindByBookingID(ctx context.Context, bookingID string) ([]entity.Ticket, error) {
+	var rows []ticketRow
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT `+ticketColumns+`
+		FROM tickets
+		WHERE booking_id = $1 AND deleted_at IS NULL
+	`, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ticketsFromRows(rows), nil
+}
+
+// FindByShowID returns not canceled tickets of not canceled bookings of the show.
+func (r *PostgresRepository) FindByShowID(ctx context.Context, showID string) ([]entity.Ticket, error) {
+	var rows []ticketRow
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT `+ticketColumns+`
+		FROM tickets
+		WHERE deleted_at IS NULL AND booking_id IN (
+			SELECT booking_id FROM bookings WHERE show_id = $1 AND canceled_at IS NULL
+		)
+		ORDER BY ticket_id
+	`, showID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ticketsFromRows(rows), nil
 }
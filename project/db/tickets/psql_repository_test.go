@@ -3,9 +3,11 @@ package tickets
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 
 	dbutils "tickets/db"
@@ -21,12 +23,12 @@ func TestTicketsRepository_Add_idempotency(t *testing.T) {
 	db := dbutils.GetDb(t)
 	repo := NewPostgresRepository(db)
 
-	ticketToAdd := entity.Ticket{
-		TicketID:      uuid.NewString(),
-		PriceAmount:   "30.00",
-		PriceCurrency: "EUR",
-		CustomerEmail: "foo@bar.com",
-	}
+	ticketToAdd := entity.NewTicket(
+		uuid.NewString(),
+		"",
+		entity.Money{Amount: decimal.RequireFromString("30.00"), Currency: "EUR"},
+		"foo@bar.com",
+	)
 
 	for i := 0; i < 2; i++ {
 		err := repo.Store(ctx, ticketToAdd)
@@ -40,3 +42,61 @@ func TestTicketsRepository_Add_idempotency(t *testing.T) {
 		require.Len(t, list, 1)
 	}
 }
+
+func TestTicketsRepository_Update_transitions(t *testing.T) {
+	ctx := context.Background()
+	container, url := dbutils.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+	db := dbutils.GetDb(t)
+	repo := NewPostgresRepository(db)
+
+	ticket := entity.NewTicket(
+		uuid.NewString(),
+		"",
+		entity.Money{Amount: decimal.RequireFromString("30.00"), Currency: "EUR"},
+		"foo@bar.com",
+	)
+	require.NoError(t, repo.Store(ctx, ticket))
+
+	_, err := repo.Update(ctx, ticket.TicketID, func(ticket entity.Ticket) (entity.Ticket, error) {
+		err := ticket.Print(time.Now())
+		return ticket, err
+	})
+	require.NoError(t, err)
+
+	_, err = repo.Update(ctx, ticket.TicketID, func(ticket entity.Ticket) (entity.Ticket, error) {
+		err := ticket.StartRefund()
+		return ticket, err
+	})
+	require.NoError(t, err)
+
+	stored, err := repo.Get(ctx, ticket.TicketID)
+	require.NoError(t, err)
+	require.Equal(t, entity.TicketStatusRefunding, stored.Status)
+	require.Nil(t, stored.RefundedAt)
+
+	_, err = repo.Update(ctx, ticket.TicketID, func(ticket entity.Ticket) (entity.Ticket, error) {
+		err := ticket.Refund(time.Now())
+		return ticket, err
+	})
+	require.NoError(t, err)
+
+	stored, err = repo.Get(ctx, ticket.TicketID)
+	require.NoError(t, err)
+	require.Equal(t, entity.TicketStatusRefunded, stored.Status)
+	require.NotNil(t, stored.PrintedAt)
+	require.NotNil(t, stored.RefundedAt)
+
+	_, err = repo.Update(ctx, ticket.TicketID, func(ticket entity.Ticket) (entity.Ticket, error) {
+		err := ticket.Cancel(time.Now())
+		return ticket, err
+	})
+	require.ErrorIs(t, err, entity.ErrIllegalTicketTransition)
+
+	stored, err = repo.Get(ctx, ticket.TicketID)
+	require.NoError(t, err)
+	require.Equal(t, entity.TicketStatusRefunded, stored.Status)
+	require.Nil(t, stored.DeletedAt)
+}
@@ -12,7 +12,6 @@ import (
 
 	"tickets/db"
 	"tickets/entity"
-	"tickets/pubsub/bus"
 	"tickets/pubsub/outbox"
 )
 
@@ -60,14 +59,9 @@ func (r PostgresRepository) Add(ctx context.Context, vipBundle entity.VipBundle)
 				return fmt.Errorf("could not insert vip bundle: %w", err)
 			}
 
-			outboxPublisher, err := outbox.NewPublisherForDb(ctx, tx)
+			eventBus, err := outbox.NewEventBusForTx(ctx, tx)
 			if err != nil {
-				return fmt.Errorf("could not create outbox published: %w", err)
-			}
-
-			eventBus, err := bus.NewEventBus(outboxPublisher)
-			if err != nil {
-				return fmt.Errorf("could not create event bus")
+				return err
 			}
 
 			err = eventBus.Publish(ctx, entity.VipBundleInitialized_v1{
@@ -94,6 +88,9 @@ func (r PostgresRepository) vipBundleByID(ctx context.Context, vipBundleID strin
 	`, vipBundleID).Scan(&payload)
 
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.VipBundle{}, entity.ErrNotFound
+		}
 		return entity.VipBundle{}, fmt.Errorf("could not get vip bundle by id: %w", err)
 	}
 
@@ -1,8 +1,31 @@
 package entity
 
+import "time"
+
 type Booking struct {
-	BookingID       string `json:"booking_id" db:"booking_id"`
-	ShowID          string `json:"show_id" db:"show_id"`
-	NumberOfTickets int    `json:"number_of_tickets" db:"number_of_tickets"`
-	CustomerEmail   string `json:"customer_email" db:"customer_email"`
+	BookingID       string     `json:"booking_id" db:"booking_id"`
+	ShowID          string     `json:"show_id" db:"show_id"`
+	NumberOfTickets int        `json:"number_of_tickets" db:"number_of_tickets"`
+	CustomerEmail   string     `json:"customer_email" db:"customer_email"`
+	CanceledAt      *time.Time `json:"canceled_at" db:"canceled_at"`
+
+	// Seats are assigned only for shows with a seat map.
+	Seats []Seat `json:"seats,omitempty" db:"-"`
+
+	// PriceCategory is the selected category, empty for the show's default one.
+	PriceCategory string        `json:"price_category,omitempty" db:"price_category"`
+	Quote         []TicketQuote `json:"quote,omitempty" db:"-"`
+
+	// PromoCode is redeemed when the booking is stored, which sets its Discount.
+	PromoCode string    `json:"promo_code,omitempty" db:"-"`
+	Discount  *Discount `json:"discount,omitempty" db:"-"`
+}
+
+// DiscountedTicketPrice returns the price of the booking's ticket after its discount.
+func (b Booking) DiscountedTicketPrice(price Money) (Money, error) {
+	if b.Discount == nil {
+		return price, nil
+	}
+
+	return b.Discount.Apply(price)
 }
@@ -1,34 +1,123 @@
 package entity
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CommandHeader is the envelope of all commands. Commands are deduplicated by their ID,
+// so a redelivered command is handled only once.
+type CommandHeader struct {
+	ID       string    `json:"id"`
+	IssuedAt time.Time `json:"issued_at"`
+
+	// IdempotencyKey is passed to external services, it's the same for all commands doing the same operation.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// ExpiresAt is set for commands which must not be handled later, expired commands are dropped.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// CorrelationID and CausationID are set by the command bus from the sending context, see EventHeader.
+	CorrelationID string `json:"correlation_id,omitempty"`
+	CausationID   string `json:"causation_id,omitempty"`
+}
+
+func NewCommandHeader() CommandHeader {
+	return CommandHeader{
+		ID:       uuid.NewString(),
+		IssuedAt: time.Now().UTC(),
+	}
+}
+
+func NewCommandHeaderWithIdempotencyKey(idempotencyKey string) CommandHeader {
+	header := NewCommandHeader()
+	header.IdempotencyKey = idempotencyKey
+
+	return header
+}
+
+func (h CommandHeader) IsExpired(now time.Time) bool {
+	return h.ExpiresAt != nil && !now.Before(*h.ExpiresAt)
+}
+
 type RefundTicket struct {
-	Header   EventHeader
+	Header   CommandHeader `json:"header"`
 	TicketID string
 }
 
 type BookShowTickets struct {
+	Header CommandHeader `json:"header"`
+
 	BookingID string `json:"booking_id"`
 
 	CustomerEmail   string `json:"customer_email"`
 	NumberOfTickets int    `json:"number_of_tickets"`
 	ShowId          string `json:"show_id"`
+	PromoCode       string `json:"promo_code,omitempty"`
 }
 
 type BookFlight struct {
-	CustomerEmail  string   `json:"customer_email"`
-	FlightID       string   `json:"to_flight_id"`
-	Passengers     []string `json:"passengers"`
-	ReferenceID    string   `json:"reference_id"`
-	IdempotencyKey string   `json:"idempotency_key"`
+	Header CommandHeader `json:"header"`
+
+	CustomerEmail string   `json:"customer_email"`
+	FlightID      string   `json:"to_flight_id"`
+	Passengers    []string `json:"passengers"`
+	ReferenceID   string   `json:"reference_id"`
+}
+
+// UnmarshalJSON reads the idempotency key of commands sent before it was moved to the header.
+func (c *BookFlight) UnmarshalJSON(data []byte) error {
+	type bookFlight BookFlight
+
+	var command struct {
+		bookFlight
+		IdempotencyKey string `json:"idempotency_key"`
+	}
+	if err := json.Unmarshal(data, &command); err != nil {
+		return err
+	}
+
+	*c = BookFlight(command.bookFlight)
+	if c.Header.IdempotencyKey == "" {
+		c.Header.IdempotencyKey = command.IdempotencyKey
+	}
+
+	return nil
 }
 
 type BookTaxi struct {
+	Header CommandHeader `json:"header"`
+
 	CustomerEmail      string `json:"customer_email"`
 	CustomerName       string `json:"customer_name"`
 	NumberOfPassengers int    `json:"number_of_passengers"`
 	ReferenceID        string `json:"reference_id"`
-	IdempotencyKey     string `json:"idempotency_key"`
+}
+
+// UnmarshalJSON reads the idempotency key of commands sent before it was moved to the header.
+func (c *BookTaxi) UnmarshalJSON(data []byte) error {
+	type bookTaxi BookTaxi
+
+	var command struct {
+		bookTaxi
+		IdempotencyKey string `json:"idempotency_key"`
+	}
+	if err := json.Unmarshal(data, &command); err != nil {
+		return err
+	}
+
+	*c = BookTaxi(command.bookTaxi)
+	if c.Header.IdempotencyKey == "" {
+		c.Header.IdempotencyKey = command.IdempotencyKey
+	}
+
+	return nil
 }
 
 type CancelFlightTickets struct {
+	Header CommandHeader `json:"header"`
+
 	FlightTicketIDs []string `json:"flight_ticket_id"`
 }
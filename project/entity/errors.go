@@ -3,7 +3,26 @@ package entity
 import "errors"
 
 var (
-	ErrNoAvailableTickets = errors.New("no available tickets")
-	ErrConflict           = errors.New("conflict")
-	ErrNotFound           = errors.New("not found")
+	ErrNoAvailableTickets   = errors.New("no available tickets")
+	ErrConflict             = errors.New("conflict")
+	ErrNotFound             = errors.New("not found")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrShowCanceled         = errors.New("show is canceled")
+	ErrSeatHoldExpired      = errors.New("seat hold expired")
+	ErrSeatNotAvailable     = errors.New("seat is not available")
+	ErrSeatMapInUse         = errors.New("seat map can't be changed when seats are booked or held")
+	ErrUnknownPriceCategory = errors.New("unknown price category")
+	ErrInvalidPromoCode     = errors.New("invalid promo code")
+
+	ErrInvalidMoney     = errors.New("invalid money")
+	ErrCurrencyMismatch = errors.New("currencies don't match")
+
+	ErrIllegalTicketTransition = errors.New("illegal ticket state transition")
+
+	ErrCapacityBelowBookedSeats = errors.New("number of tickets can't be lower than booked seats")
+	ErrCapacityDefinedBySeatMap = errors.New("number of tickets of the show is defined by its seat map")
+
+	ErrIdempotencyKeyReused      = errors.New("idempotency key was already used with a different request")
+	ErrIdempotentRequestInFlight = errors.New("request with the same idempotency key is still being processed")
+	ErrCommandInFlight           = errors.New("command with the same ID is still being handled")
 )
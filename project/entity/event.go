@@ -9,4 +9,7 @@ type DataLakeEvent struct {
 	PublishedAt time.Time `db:"published_at"`
 	Name        string    `db:"event_name"`
 	Payload     []byte    `db:"event_payload"`
+
+	CorrelationID string `db:"correlation_id"`
+	CausationID   string `db:"causation_id"`
 }
@@ -14,6 +14,12 @@ type EventHeader struct {
 	ID             string    `json:"id"`
 	PublishedAt    time.Time `json:"published_at"`
 	IdempotencyKey string    `json:"idempotency_key"`
+
+	// CorrelationID is the ID of the HTTP request, which started the flow the event is part of,
+	// CausationID is the ID of the message or the request which directly caused the event.
+	// Both are set by the event bus from the publishing context.
+	CorrelationID string `json:"correlation_id,omitempty"`
+	CausationID   string `json:"causation_id,omitempty"`
 }
 
 func NewEventHeader() EventHeader {
@@ -35,8 +41,16 @@ type TicketBookingConfirmed_v1 struct {
 	Header        EventHeader `json:"header"`
 	TicketID      string      `json:"ticket_id"`
 	CustomerEmail string      `json:"customer_email"`
-	Price         Money       `json:"price"`
 	BookingID     string      `json:"booking_id"`
+
+	// Price is the price paid for the ticket, after the discount of its booking.
+	Price Money `json:"price"`
+
+	// ListPrice is the price before the discount, it's set only for discounted tickets.
+	ListPrice *Money `json:"list_price,omitempty"`
+
+	// Seat is set only for shows with a seat map.
+	Seat *Seat `json:"seat,omitempty"`
 }
 
 func (e TicketBookingConfirmed_v1) IsInternal() bool {
@@ -47,8 +61,10 @@ type TicketBookingCanceled_v1 struct {
 	Header        EventHeader `json:"header"`
 	TicketID      string      `json:"ticket_id"`
 	CustomerEmail string      `json:"customer_email"`
-	Price         Money       `json:"price"`
 	BookingID     string      `json:"booking_id"`
+
+	// Price is the price paid for the ticket, after the discount of its booking.
+	Price Money `json:"price"`
 }
 
 func (e TicketBookingCanceled_v1) IsInternal() bool {
@@ -71,12 +87,31 @@ type BookingMade_v1 struct {
 	NumberOfTickets int         `json:"number_of_tickets"`
 	CustomerEmail   string      `json:"customer_email"`
 	ShowID          string      `json:"show_id"`
+	Seats           []Seat      `json:"seats,omitempty"`
+
+	// Quote is empty for shows priced outside of this service.
+	Quote []TicketQuote `json:"quote,omitempty"`
+
+	// Discount is applied to prices of the booking's tickets, it's nil for bookings without a promo code.
+	Discount *Discount `json:"discount,omitempty"`
 }
 
 func (e BookingMade_v1) IsInternal() bool {
 	return false
 }
 
+type BookingCanceled_v1 struct {
+	Header          EventHeader `json:"header"`
+	BookingID       string      `json:"booking_id"`
+	NumberOfTickets int         `json:"number_of_tickets"`
+	CustomerEmail   string      `json:"customer_email"`
+	ShowID          string      `json:"show_id"`
+}
+
+func (e BookingCanceled_v1) IsInternal() bool {
+	return false
+}
+
 type TicketReceiptIssued_v1 struct {
 	Header        EventHeader `json:"header"`
 	TicketID      string      `json:"ticket_id"`
@@ -97,6 +132,17 @@ func (e TicketRefunded_v1) IsInternal() bool {
 	return false
 }
 
+// TicketRefundFailed_v1 is published when the ticket can't be refunded, and the refund won't be retried.
+type TicketRefundFailed_v1 struct {
+	Header   EventHeader `json:"header"`
+	TicketID string      `json:"ticket_id"`
+	Reason   string      `json:"reason"`
+}
+
+func (e TicketRefundFailed_v1) IsInternal() bool {
+	return false
+}
+
 type InternalOpsReadModelUpdated struct {
 	Header    EventHeader `json:"header"`
 	BookingID string      `json:"booking_id"`
@@ -106,6 +152,19 @@ func (e InternalOpsReadModelUpdated) IsInternal() bool {
 	return true
 }
 
+// InternalVipBundleStepTimedOut is scheduled when a step of the VIP bundle starts and published at its deadline,
+// even when the step completed in time.
+type InternalVipBundleStepTimedOut struct {
+	Header      EventHeader `json:"header"`
+	VipBundleID string      `json:"vip_bundle_id"`
+	Step        string      `json:"step"`
+	Deadline    time.Time   `json:"deadline"`
+}
+
+func (e InternalVipBundleStepTimedOut) IsInternal() bool {
+	return true
+}
+
 type VipBundleInitialized_v1 struct {
 	Header      EventHeader `json:"header"`
 	VipBundleID string      `json:"vip_bundle_id"`
@@ -185,3 +244,76 @@ type TaxiBookingFailed_v1 struct {
 func (t TaxiBookingFailed_v1) IsInternal() bool {
 	return false
 }
+
+type ShowUpdated_v1 struct {
+	Header          EventHeader `json:"header"`
+	ShowID          string      `json:"show_id"`
+	NumberOfTickets int         `json:"number_of_tickets"`
+	StartTime       time.Time   `json:"start_time"`
+	Venue           string      `json:"venue"`
+
+	PriceCategories []PriceCategory `json:"price_categories,omitempty"`
+}
+
+func (e ShowUpdated_v1) IsInternal() bool {
+	return false
+}
+
+type ShowCanceled_v1 struct {
+	Header EventHeader `json:"header"`
+	ShowID string      `json:"show_id"`
+}
+
+func (e ShowCanceled_v1) IsInternal() bool {
+	return false
+}
+
+type SeatsHeld_v1 struct {
+	Header          EventHeader `json:"header"`
+	HoldID          string      `json:"hold_id"`
+	ShowID          string      `json:"show_id"`
+	NumberOfTickets int         `json:"number_of_tickets"`
+	CustomerEmail   string      `json:"customer_email"`
+	ExpiresAt       time.Time   `json:"expires_at"`
+}
+
+func (e SeatsHeld_v1) IsInternal() bool {
+	return false
+}
+
+type SeatHoldExpired_v1 struct {
+	Header          EventHeader `json:"header"`
+	HoldID          string      `json:"hold_id"`
+	ShowID          string      `json:"show_id"`
+	NumberOfTickets int         `json:"number_of_tickets"`
+}
+
+func (e SeatHoldExpired_v1) IsInternal() bool {
+	return false
+}
+
+type WaitlistJoined_v1 struct {
+	Header          EventHeader `json:"header"`
+	EntryID         string      `json:"entry_id"`
+	ShowID          string      `json:"show_id"`
+	NumberOfTickets int         `json:"number_of_tickets"`
+	CustomerEmail   string      `json:"customer_email"`
+}
+
+func (e WaitlistJoined_v1) IsInternal() bool {
+	return false
+}
+
+type WaitlistOfferMade_v1 struct {
+	Header          EventHeader `json:"header"`
+	EntryID         string      `json:"entry_id"`
+	ShowID          string      `json:"show_id"`
+	HoldID          string      `json:"hold_id"`
+	NumberOfTickets int         `json:"number_of_tickets"`
+	CustomerEmail   string      `json:"customer_email"`
+	ExpiresAt       time.Time   `json:"expires_at"`
+}
+
+func (e WaitlistOfferMade_v1) IsInternal() bool {
+	return false
+}
@@ -0,0 +1,8 @@
+package entity
+
+// IdempotentResponse is the HTTP response stored for an idempotency key, so it can be replayed on retries.
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
@@ -1,6 +1,162 @@
 package entity
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// currencyMinorUnits maps active ISO-4217 currency codes to the number of their minor units (decimal places).
+var currencyMinorUnits = func() map[string]int32 {
+	units := map[string]int32{}
+
+	for _, code := range strings.Fields(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD BTN BWP BYN BZD
+		CAD CDF CHE CHF CHW CNY COP COU CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS
+		GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP LKR LRD LSL
+		MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK
+		PHP PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS
+		TMT TOP TRY TTD TWD TZS UAH USD USN UYU UZS VED VES WST XCD YER ZAR ZMW ZWL
+	`) {
+		units[code] = 2
+	}
+	for _, code := range strings.Fields(`BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF`) {
+		units[code] = 0
+	}
+	for _, code := range strings.Fields(`BHD IQD JOD KWD LYD OMR TND`) {
+		units[code] = 3
+	}
+	for _, code := range strings.Fields(`CLF UYW`) {
+		units[code] = 4
+	}
+
+	return units
+}()
+
+// Money is an exact decimal amount in an ISO-4217 currency.
+// The amount is marshaled to JSON as a string with the currency's minor units (like "50.00"),
+// so no precision is lost by clients parsing it as a float.
 type Money struct {
-	Amount   string `json:"amount"`
-	Currency string `json:"currency"`
+	Amount   decimal.Decimal `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// NewMoney returns valid money, see Money.Validate.
+func NewMoney(amount decimal.Decimal, currency string) (Money, error) {
+	money := Money{Amount: amount, Currency: currency}
+	if err := money.Validate(); err != nil {
+		return Money{}, err
+	}
+
+	return money, nil
+}
+
+// ParseMoney parses the decimal amount and returns valid money, see Money.Validate.
+func ParseMoney(amount string, currency string) (Money, error) {
+	parsedAmount, err := decimal.NewFromString(amount)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: amount %q is not a decimal number", ErrInvalidMoney, amount)
+	}
+
+	return NewMoney(parsedAmount, currency)
+}
+
+// Validate checks that the currency is an active ISO-4217 code
+// and that the amount has no more decimal places than the currency's minor units.
+func (m Money) Validate() error {
+	minorUnits, ok := currencyMinorUnits[m.Currency]
+	if !ok {
+		return fmt.Errorf("%w: unknown currency %q", ErrInvalidMoney, m.Currency)
+	}
+
+	if !m.Amount.Equal(m.Amount.Truncate(minorUnits)) {
+		return fmt.Errorf("%w: %s has more than %d decimal places", ErrInvalidMoney, m.Amount, minorUnits)
+	}
+
+	return nil
+}
+
+// Add returns the sum of both amounts, which must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: m.Amount.Add(other.Amount), Currency: m.Currency}, nil
+}
+
+// Sub returns the difference of both amounts, which must be in the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: m.Amount.Sub(other.Amount), Currency: m.Currency}, nil
+}
+
+// Mul multiplies the amount exactly. The result may have more decimal places than the currency allows,
+// so it should be rounded with Round before it's charged.
+func (m Money) Mul(factor decimal.Decimal) Money {
+	return Money{Amount: m.Amount.Mul(factor), Currency: m.Currency}
+}
+
+// Round rounds the amount half away from zero to the minor units of the currency.
+// Amounts in unknown currencies are rounded to 2 decimal places.
+func (m Money) Round() Money {
+	return Money{Amount: m.Amount.Round(m.minorUnits()), Currency: m.Currency}
+}
+
+// AmountString formats the amount with all minor units of the currency, so 50 USD is "50.00".
+// It should be used whenever the amount is sent outside of the service.
+// Amounts with more decimal places than the currency allows are formatted without rounding.
+func (m Money) AmountString() string {
+	minorUnits := m.minorUnits()
+	if !m.Amount.Equal(m.Amount.Truncate(minorUnits)) {
+		return m.Amount.String()
+	}
+
+	return m.Amount.StringFixed(minorUnits)
+}
+
+// Equal compares amounts numerically, so 100 equals 100.00.
+func (m Money) Equal(other Money) bool {
+	return m.Currency == other.Currency && m.Amount.Equal(other.Amount)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+func (m Money) String() string {
+	return m.AmountString() + " " + m.Currency
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{
+		Amount:   m.AmountString(),
+		Currency: m.Currency,
+	})
+}
+
+// minorUnits returns the number of decimal places of the currency, it's 2 for unknown currencies.
+func (m Money) minorUnits() int32 {
+	minorUnits, ok := currencyMinorUnits[m.Currency]
+	if !ok {
+		return 2
+	}
+
+	return minorUnits
+}
+
+func (m Money) checkCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+
+	return nil
 }
@@ -0,0 +1,136 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoney_Validate(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Amount   string
+		Currency string
+		Valid    bool
+	}{
+		{Name: "whole_amount", Amount: "50", Currency: "USD", Valid: true},
+		{Name: "minor_units", Amount: "50.99", Currency: "EUR", Valid: true},
+		{Name: "trailing_zeros", Amount: "50.100", Currency: "USD", Valid: true},
+		{Name: "currency_without_minor_units", Amount: "1000", Currency: "JPY", Valid: true},
+		{Name: "three_minor_units", Amount: "1.125", Currency: "KWD", Valid: true},
+		{Name: "too_many_decimal_places", Amount: "50.001", Currency: "USD", Valid: false},
+		{Name: "decimal_places_of_currency_without_minor_units", Amount: "1000.5", Currency: "JPY", Valid: false},
+		{Name: "unknown_currency", Amount: "50", Currency: "XYZ", Valid: false},
+		{Name: "lowercase_currency", Amount: "50", Currency: "usd", Valid: false},
+		{Name: "empty_currency", Amount: "50", Currency: "", Valid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := ParseMoney(tc.Amount, tc.Currency)
+			if tc.Valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidMoney)
+			}
+		})
+	}
+
+	_, err := ParseMoney("fifty", "USD")
+	assert.ErrorIs(t, err, ErrInvalidMoney)
+}
+
+func TestMoney_arithmetic(t *testing.T) {
+	usd := func(amount string) Money {
+		return Money{Amount: decimal.RequireFromString(amount), Currency: "USD"}
+	}
+
+	sum, err := usd("0.10").Add(usd("0.20"))
+	require.NoError(t, err)
+	assert.True(t, sum.Equal(usd("0.30")), "sum should be exact, got %s", sum)
+
+	difference, err := usd("50.00").Sub(usd("49.99"))
+	require.NoError(t, err)
+	assert.True(t, difference.Equal(usd("0.01")), "difference should be exact, got %s", difference)
+
+	product := usd("19.99").Mul(decimal.NewFromInt(3))
+	assert.True(t, product.Equal(usd("59.97")), "product should be exact, got %s", product)
+
+	eur := Money{Amount: decimal.NewFromInt(1), Currency: "EUR"}
+
+	_, err = usd("1").Add(eur)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = usd("1").Sub(eur)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestMoney_Round(t *testing.T) {
+	testCases := []struct {
+		Amount   string
+		Currency string
+		Expected string
+	}{
+		{Amount: "10.005", Currency: "USD", Expected: "10.01"},
+		{Amount: "10.004", Currency: "USD", Expected: "10"},
+		{Amount: "-10.005", Currency: "USD", Expected: "-10.01"},
+		{Amount: "999.5", Currency: "JPY", Expected: "1000"},
+		{Amount: "1.0005", Currency: "KWD", Expected: "1.001"},
+		{Amount: "1.00005", Currency: "CLF", Expected: "1.0001"},
+		{Amount: "10.005", Currency: "XYZ", Expected: "10.01"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Amount+" "+tc.Currency, func(t *testing.T) {
+			rounded := Money{Amount: decimal.RequireFromString(tc.Amount), Currency: tc.Currency}.Round()
+
+			assert.True(
+				t,
+				rounded.Amount.Equal(decimal.RequireFromString(tc.Expected)),
+				"expected %s, got %s", tc.Expected, rounded.Amount,
+			)
+			assert.Equal(t, tc.Currency, rounded.Currency)
+		})
+	}
+}
+
+func TestMoney_AmountString(t *testing.T) {
+	testCases := []struct {
+		Amount   string
+		Currency string
+		Expected string
+	}{
+		{Amount: "50.00", Currency: "USD", Expected: "50.00"},
+		{Amount: "50", Currency: "USD", Expected: "50.00"},
+		{Amount: "49.9", Currency: "EUR", Expected: "49.90"},
+		{Amount: "1000", Currency: "JPY", Expected: "1000"},
+		{Amount: "1.5", Currency: "KWD", Expected: "1.500"},
+		{Amount: "0.125", Currency: "XYZ", Expected: "0.125"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Amount+" "+tc.Currency, func(t *testing.T) {
+			money := Money{Amount: decimal.RequireFromString(tc.Amount), Currency: tc.Currency}
+
+			assert.Equal(t, tc.Expected, money.AmountString())
+			assert.Equal(t, tc.Expected+" "+tc.Currency, money.String())
+		})
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var money Money
+	err := json.Unmarshal([]byte(`{"amount": "50.00", "currency": "USD"}`), &money)
+	require.NoError(t, err)
+
+	marshaled, err := json.Marshal(money)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount": "50.00", "currency": "USD"}`, string(marshaled))
+
+	err = json.Unmarshal([]byte(`{"amount": 19.99, "currency": "USD"}`), &money)
+	require.NoError(t, err)
+	assert.True(t, money.Amount.Equal(decimal.RequireFromString("19.99")))
+}
@@ -1,21 +1,79 @@
 package entity
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type OpsBooking struct {
-	BookingID string    `json:"booking_id"`
-	BookedAt  time.Time `json:"booked_at"`
+	BookingID     string    `json:"booking_id"`
+	BookedAt      time.Time `json:"booked_at"`
+	ShowID        string    `json:"show_id"`
+	CustomerEmail string    `json:"customer_email"`
 
 	Tickets map[string]OpsTicket `json:"tickets"`
 
 	LastUpdate time.Time `json:"last_update"`
 }
 
+// OpsBookingUpdate is the booking as it was at the position of the updates stream.
+type OpsBookingUpdate struct {
+	Booking OpsBooking
+
+	// Position is opaque, it's used to resume the updates stream after this update.
+	Position string
+}
+
+func (b OpsBooking) HasRefunds() bool {
+	for _, ticket := range b.Tickets {
+		if !ticket.RefundedAt.IsZero() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllTicketsPrinted returns true when the booking has tickets, and all of them are printed.
+func (b OpsBooking) AllTicketsPrinted() bool {
+	if len(b.Tickets) == 0 {
+		return false
+	}
+
+	for _, ticket := range b.Tickets {
+		if ticket.PrintedAt.IsZero() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReceiptIssueDates returns unique dates (in UTC, formatted as YYYY-MM-DD) when receipts of the booking were issued.
+func (b OpsBooking) ReceiptIssueDates() []string {
+	unique := map[string]struct{}{}
+	for _, ticket := range b.Tickets {
+		if ticket.ReceiptIssuedAt.IsZero() {
+			continue
+		}
+		unique[ticket.ReceiptIssuedAt.UTC().Format(time.DateOnly)] = struct{}{}
+	}
+
+	dates := make([]string, 0, len(unique))
+	for date := range unique {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	return dates
+}
+
 type OpsTicket struct {
-	PriceAmount   string `json:"price_amount"`
-	PriceCurrency string `json:"price_currency"`
+	Price         Money  `json:"price"`
 	CustomerEmail string `json:"customer_email"`
 
 	PrintedAt       time.Time `json:"printed_at"`
@@ -27,3 +85,49 @@ type OpsTicket struct {
 	ConfirmedAt time.Time `json:"confirmed_at"`
 	RefundedAt  time.Time `json:"refunded_at"`
 }
+
+// UnmarshalJSON reads also read models stored before the price was a Money object.
+func (t *OpsTicket) UnmarshalJSON(data []byte) error {
+	type opsTicket OpsTicket
+	var ticket struct {
+		opsTicket
+		PriceAmount   string `json:"price_amount"`
+		PriceCurrency string `json:"price_currency"`
+	}
+	if err := json.Unmarshal(data, &ticket); err != nil {
+		return err
+	}
+
+	*t = OpsTicket(ticket.opsTicket)
+
+	if ticket.PriceAmount != "" && t.Price.Currency == "" {
+		amount, err := decimal.NewFromString(ticket.PriceAmount)
+		if err != nil {
+			return fmt.Errorf("could not parse price amount: %w", err)
+		}
+		t.Price = Money{Amount: amount, Currency: ticket.PriceCurrency}
+	}
+
+	return nil
+}
+
+const (
+	OpsBookingsSortBookedAtAsc  = "booked_at"
+	OpsBookingsSortBookedAtDesc = "-booked_at"
+)
+
+type OpsBookingsFilter struct {
+	CustomerEmail    string
+	ShowID           string
+	BookedFrom       *time.Time
+	BookedTo         *time.Time
+	HasRefunds       *bool
+	Printed          *bool
+	ReceiptIssueDate string
+
+	// Sort is one of OpsBookingsSort* constants, newest bookings are returned first by default.
+	Sort string
+
+	Cursor string
+	Limit  int
+}
@@ -0,0 +1,122 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PriceCategory is a price tier of the show's tickets. Seats of the show's seat map are priced by their category.
+type PriceCategory struct {
+	Name  string `json:"name"`
+	Price Money  `json:"price"`
+}
+
+// TicketQuote is the price of a booked ticket, quoted when the booking was made.
+type TicketQuote struct {
+	Category string `json:"category"`
+	Seat     *Seat  `json:"seat,omitempty"`
+	Price    Money  `json:"price"`
+}
+
+// ValidatePriceCategories checks that categories have unique names and positive prices in the same currency.
+func ValidatePriceCategories(categories []PriceCategory) error {
+	names := make(map[string]struct{}, len(categories))
+
+	for _, category := range categories {
+		if category.Name == "" {
+			return errors.New("price category name is required")
+		}
+		if _, ok := names[category.Name]; ok {
+			return fmt.Errorf("price category %s is duplicated", category.Name)
+		}
+		names[category.Name] = struct{}{}
+
+		if err := category.Price.Validate(); err != nil {
+			return fmt.Errorf("price of category %s: %w", category.Name, err)
+		}
+		if !category.Price.Amount.IsPositive() {
+			return fmt.Errorf("price of category %s must be a positive number", category.Name)
+		}
+		if category.Price.Currency != categories[0].Price.Currency {
+			return errors.New("all price categories must have the same currency")
+		}
+	}
+
+	return nil
+}
+
+// PriceCategory returns the show's price category with the name.
+// The first category is the default one, which is returned for an empty name.
+func (s Show) PriceCategory(name string) (PriceCategory, error) {
+	if len(s.PriceCategories) == 0 {
+		return PriceCategory{}, fmt.Errorf("%w: show has no price categories", ErrUnknownPriceCategory)
+	}
+	if name == "" {
+		return s.PriceCategories[0], nil
+	}
+
+	for _, category := range s.PriceCategories {
+		if category.Name == name {
+			return category, nil
+		}
+	}
+
+	return PriceCategory{}, fmt.Errorf("%w: %s", ErrUnknownPriceCategory, name)
+}
+
+// QuoteTickets returns prices of booked tickets. Seats are priced by their category,
+// tickets without seats or seats without a category by the selected one.
+// Shows without price categories are priced outside of this service, so no quote is returned.
+func (s Show) QuoteTickets(category string, numberOfTickets int, seats []ShowSeat) ([]TicketQuote, error) {
+	if len(s.PriceCategories) == 0 {
+		return nil, nil
+	}
+
+	selectedCategory, err := s.PriceCategory(category)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seats) == 0 {
+		quote := make([]TicketQuote, 0, numberOfTickets)
+		for i := 0; i < numberOfTickets; i++ {
+			quote = append(quote, TicketQuote{
+				Category: selectedCategory.Name,
+				Price:    selectedCategory.Price,
+			})
+		}
+		return quote, nil
+	}
+
+	quote := make([]TicketQuote, 0, len(seats))
+	for _, seat := range seats {
+		seatCategory := selectedCategory
+		if seat.Category != "" {
+			seatCategory, err = s.PriceCategory(seat.Category)
+			if err != nil {
+				return nil, fmt.Errorf("could not price %s: %w", seat.Seat, err)
+			}
+		}
+
+		seat := seat.Seat
+		quote = append(quote, TicketQuote{
+			Category: seatCategory.Name,
+			Seat:     &seat,
+			Price:    seatCategory.Price,
+		})
+	}
+
+	return quote, nil
+}
+
+// QuotedTicketPrice returns the quoted price of the booking's ticket.
+// Seat is nil for shows without a seat map. It returns false when the booking has no quote.
+func (b Booking) QuotedTicketPrice(seat *Seat) (Money, bool) {
+	for _, ticketQuote := range b.Quote {
+		if seat == nil || (ticketQuote.Seat != nil && *ticketQuote.Seat == *seat) {
+			return ticketQuote.Price, true
+		}
+	}
+
+	return Money{}, false
+}
@@ -0,0 +1,127 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DiscountTypePercentage = "percentage"
+	DiscountTypeFixed      = "fixed"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Discount is subtracted from the price of each ticket of a booking.
+type Discount struct {
+	PromoCode string `json:"promo_code"`
+	Type      string `json:"type"`
+
+	// Percentage is set for percentage discounts, Amount for fixed ones.
+	Percentage *decimal.Decimal `json:"percentage,omitempty"`
+	Amount     *Money           `json:"amount,omitempty"`
+}
+
+// Apply returns the discounted price of a ticket. Prices are never discounted below zero.
+func (d Discount) Apply(price Money) (Money, error) {
+	var discount Money
+	switch d.Type {
+	case DiscountTypePercentage:
+		if d.Percentage == nil {
+			return Money{}, errors.New("percentage of the discount is not set")
+		}
+		discount = price.Mul(d.Percentage.Div(hundred)).Round()
+	case DiscountTypeFixed:
+		if d.Amount == nil {
+			return Money{}, errors.New("amount of the discount is not set")
+		}
+		discount = *d.Amount
+	default:
+		return Money{}, fmt.Errorf("unknown discount type %q", d.Type)
+	}
+
+	discounted, err := price.Sub(discount)
+	if err != nil {
+		return Money{}, err
+	}
+	if discounted.Amount.IsNegative() {
+		return Money{Amount: decimal.Zero, Currency: price.Currency}, nil
+	}
+
+	return discounted, nil
+}
+
+// Promotion is a promo code giving a discount on tickets of all shows, or of one show when ShowID is set.
+// Each booking made with the code is one use of it.
+type Promotion struct {
+	Code string `json:"code"`
+
+	// Discount's PromoCode is the promotion's code.
+	Discount Discount `json:"discount"`
+
+	ShowID     *string    `json:"show_id,omitempty"`
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+
+	// UsageLimit is nil for promotions which can be used any number of times.
+	UsageLimit *int `json:"usage_limit,omitempty"`
+	UsedCount  int  `json:"used_count"`
+}
+
+func (p Promotion) Validate() error {
+	if p.Code == "" {
+		return errors.New("promo code is required")
+	}
+
+	switch p.Discount.Type {
+	case DiscountTypePercentage:
+		if p.Discount.Percentage == nil || p.Discount.Amount != nil {
+			return errors.New("percentage discount must have only a percentage")
+		}
+		if !p.Discount.Percentage.IsPositive() || p.Discount.Percentage.GreaterThan(hundred) {
+			return errors.New("percentage must be greater than 0 and at most 100")
+		}
+	case DiscountTypeFixed:
+		if p.Discount.Amount == nil || p.Discount.Percentage != nil {
+			return errors.New("fixed discount must have only an amount")
+		}
+		if err := p.Discount.Amount.Validate(); err != nil {
+			return fmt.Errorf("amount of the discount: %w", err)
+		}
+		if !p.Discount.Amount.Amount.IsPositive() {
+			return errors.New("amount of the discount must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown discount type %q", p.Discount.Type)
+	}
+
+	if p.ValidFrom != nil && p.ValidUntil != nil && !p.ValidFrom.Before(*p.ValidUntil) {
+		return errors.New("valid_from must be before valid_until")
+	}
+	if p.UsageLimit != nil && *p.UsageLimit <= 0 {
+		return errors.New("usage limit must be positive")
+	}
+
+	return nil
+}
+
+// CheckApplicable returns ErrInvalidPromoCode when the promotion can't be used for a booking of the show now.
+func (p Promotion) CheckApplicable(showID string, now time.Time) error {
+	if p.ShowID != nil && *p.ShowID != showID {
+		return fmt.Errorf("%w: %s is not valid for this show", ErrInvalidPromoCode, p.Code)
+	}
+	if p.ValidFrom != nil && now.Before(*p.ValidFrom) {
+		return fmt.Errorf("%w: %s is not valid yet", ErrInvalidPromoCode, p.Code)
+	}
+	if p.ValidUntil != nil && !now.Before(*p.ValidUntil) {
+		return fmt.Errorf("%w: %s has expired", ErrInvalidPromoCode, p.Code)
+	}
+	if p.UsageLimit != nil && p.UsedCount >= *p.UsageLimit {
+		return fmt.Errorf("%w: %s has reached its usage limit", ErrInvalidPromoCode, p.Code)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Seat is an assigned place in the show's venue.
+type Seat struct {
+	Section string `json:"section" db:"section"`
+	Row     string `json:"row" db:"seat_row"`
+	Number  int    `json:"number" db:"seat_number"`
+}
+
+func (s Seat) String() string {
+	return fmt.Sprintf("section %s, row %s, seat %d", s.Section, s.Row, s.Number)
+}
+
+// ShowSeat is a seat of the show's seat map. Shows with a seat map have one seat per ticket.
+type ShowSeat struct {
+	Seat
+	Category string `json:"category" db:"category"`
+
+	// BookingID is set when the seat is booked, TicketID when the ticket of the booking is confirmed.
+	BookingID *string `json:"-" db:"booking_id"`
+	TicketID  *string `json:"-" db:"ticket_id"`
+}
+
+func (s ShowSeat) Available() bool {
+	return s.BookingID == nil
+}
+
+// SeatMap describes seats of the show's venue, grouped into sections and rows.
+type SeatMap struct {
+	Sections []SeatMapSection `json:"sections"`
+}
+
+type SeatMapSection struct {
+	Name     string       `json:"name"`
+	Category string       `json:"category"`
+	Rows     []SeatMapRow `json:"rows"`
+}
+
+// SeatMapRow has seats numbered from 1 to Seats.
+type SeatMapRow struct {
+	Row   string `json:"row"`
+	Seats int    `json:"seats"`
+}
+
+// ShowSeats returns all seats of the seat map. Seats must be unique.
+func (m SeatMap) ShowSeats() ([]ShowSeat, error) {
+	var seats []ShowSeat
+	rows := make(map[[2]string]struct{})
+
+	for _, section := range m.Sections {
+		if section.Name == "" {
+			return nil, errors.New("section name is required")
+		}
+		if len(section.Rows) == 0 {
+			return nil, fmt.Errorf("section %s has no rows", section.Name)
+		}
+
+		for _, row := range section.Rows {
+			if row.Row == "" {
+				return nil, fmt.Errorf("row name in section %s is required", section.Name)
+			}
+			if row.Seats < 1 {
+				return nil, fmt.Errorf("row %s in section %s has no seats", row.Row, section.Name)
+			}
+
+			key := [2]string{section.Name, row.Row}
+			if _, ok := rows[key]; ok {
+				return nil, fmt.Errorf("row %s in section %s is duplicated", row.Row, section.Name)
+			}
+			rows[key] = struct{}{}
+
+			for number := 1; number <= row.Seats; number++ {
+				seats = append(seats, ShowSeat{
+					Seat:     Seat{Section: section.Name, Row: row.Row, Number: number},
+					Category: section.Category,
+				})
+			}
+		}
+	}
+
+	if len(seats) == 0 {
+		return nil, errors.New("seat map has no seats")
+	}
+
+	return seats, nil
+}
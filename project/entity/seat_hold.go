@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// SeatHold reserves seats of the show for a limited time, until it's confirmed as a booking or expires.
+type SeatHold struct {
+	HoldID          string     `json:"hold_id" db:"hold_id"`
+	ShowID          string     `json:"show_id" db:"show_id"`
+	NumberOfTickets int        `json:"number_of_tickets" db:"number_of_tickets"`
+	CustomerEmail   string     `json:"customer_email" db:"customer_email"`
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	BookingID       *string    `json:"booking_id" db:"booking_id"`
+	ConfirmedAt     *time.Time `json:"confirmed_at" db:"confirmed_at"`
+	ExpiredAt       *time.Time `json:"expired_at" db:"expired_at"`
+}
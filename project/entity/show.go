@@ -9,4 +9,27 @@ type Show struct {
 	StartTime       time.Time `json:"start_time" db:"start_time"`
 	Title           string    `json:"title" db:"title"`
 	Venue           string    `json:"venue" db:"venue"`
+
+	CanceledAt *time.Time `json:"canceled_at" db:"canceled_at"`
+
+	// PriceCategories are empty for shows priced outside of this service.
+	PriceCategories []PriceCategory `json:"price_categories,omitempty" db:"-"`
+}
+
+// ShowUpdate contains the changed fields of the show, nil fields are not changed.
+type ShowUpdate struct {
+	NumberOfTickets *int
+	StartTime       *time.Time
+	Venue           *string
+	PriceCategories *[]PriceCategory
+}
+
+type ShowsFilter struct {
+	Venue         string
+	Title         string
+	StartTimeFrom *time.Time
+	StartTimeTo   *time.Time
+
+	Cursor string
+	Limit  int
 }
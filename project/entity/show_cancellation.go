@@ -0,0 +1,282 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+)
+
+const (
+	ShowCancellationStatusInProgress = "in_progress"
+	ShowCancellationStatusCompleted  = "completed"
+)
+
+// ShowCancellation tracks refunds of all confirmed tickets of a canceled show,
+// including tickets confirmed after the show was canceled.
+// It's completed when each ticket is either refunded, or its refund failed permanently.
+type ShowCancellation struct {
+	ShowID     string    `json:"show_id"`
+	CanceledAt time.Time `json:"canceled_at"`
+
+	TicketIDs         []string `json:"ticket_ids"`
+	RefundedTicketIDs []string `json:"refunded_ticket_ids"`
+	FailedTicketIDs   []string `json:"failed_ticket_ids"`
+
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+func NewShowCancellation(showID string, canceledAt time.Time, ticketIDs []string) (*ShowCancellation, error) {
+	if showID == "" {
+		return nil, fmt.Errorf("show id must be set")
+	}
+	if canceledAt.IsZero() {
+		return nil, fmt.Errorf("canceled at must be set")
+	}
+
+	sc := &ShowCancellation{
+		ShowID:            showID,
+		CanceledAt:        canceledAt,
+		TicketIDs:         ticketIDs,
+		RefundedTicketIDs: []string{},
+		FailedTicketIDs:   []string{},
+	}
+	if sc.TicketIDs == nil {
+		sc.TicketIDs = []string{}
+	}
+	sc.completeIfAllSettled(canceledAt)
+
+	return sc, nil
+}
+
+func (s ShowCancellation) Status() string {
+	if s.CompletedAt != nil {
+		return ShowCancellationStatusCompleted
+	}
+
+	return ShowCancellationStatusInProgress
+}
+
+func (s ShowCancellation) IsRefunded(ticketID string) bool {
+	return containsID(s.RefundedTicketIDs, ticketID)
+}
+
+func (s ShowCancellation) HasTicket(ticketID string) bool {
+	return containsID(s.TicketIDs, ticketID)
+}
+
+// AddTicket adds the ticket confirmed after the show was canceled, so it's refunded as well.
+// Adding already added ticket does nothing.
+func (s *ShowCancellation) AddTicket(ticketID string) {
+	if s.HasTicket(ticketID) {
+		return
+	}
+
+	s.TicketIDs = append(s.TicketIDs, ticketID)
+	s.CompletedAt = nil
+}
+
+// MarkRefunded records the ticket's refund, re-deliveries and unknown tickets are ignored.
+// Ticket which failed to refund before, but was refunded later is no longer failed.
+func (s *ShowCancellation) MarkRefunded(ticketID string, refundedAt time.Time) {
+	if s.IsRefunded(ticketID) || !s.HasTicket(ticketID) {
+		return
+	}
+
+	s.RefundedTicketIDs = append(s.RefundedTicketIDs, ticketID)
+	s.FailedTicketIDs = removeID(s.FailedTicketIDs, ticketID)
+
+	s.completeIfAllSettled(refundedAt)
+}
+
+// MarkRefundFailed records that the ticket's refund failed permanently.
+// Refunded and unknown tickets are ignored.
+func (s *ShowCancellation) MarkRefundFailed(ticketID string, failedAt time.Time) {
+	if s.IsRefunded(ticketID) || !s.HasTicket(ticketID) || containsID(s.FailedTicketIDs, ticketID) {
+		return
+	}
+
+	s.FailedTicketIDs = append(s.FailedTicketIDs, ticketID)
+
+	s.completeIfAllSettled(failedAt)
+}
+
+func (s *ShowCancellation) completeIfAllSettled(now time.Time) {
+	if s.CompletedAt == nil && len(s.RefundedTicketIDs)+len(s.FailedTicketIDs) == len(s.TicketIDs) {
+		s.CompletedAt = &now
+	}
+}
+
+func containsID(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func removeID(ids []string, id string) []string {
+	result := make([]string, 0, len(ids))
+	for _, i := range ids {
+		if i != id {
+			result = append(result, i)
+		}
+	}
+
+	return result
+}
+
+type ShowCancellationRepository interface {
+	// Add stores the show cancellation, if it doesn't exist yet. It returns the stored cancellation.
+	Add(ctx context.Context, showCancellation ShowCancellation) (ShowCancellation, error)
+	Get(ctx context.Context, showID string) (ShowCancellation, error)
+
+	// UpdateByTicketID updates the cancellation of the ticket's show.
+	// It returns ErrNotFound when the ticket's show is not canceled.
+	UpdateByTicketID(
+		ctx context.Context,
+		ticketID string,
+		updateFn func(showCancellation ShowCancellation) (ShowCancellation, error),
+	) (ShowCancellation, error)
+
+	// UpdateByBookingID updates the cancellation of the booking's show.
+	// It returns ErrNotFound when the booking's show is not canceled.
+	UpdateByBookingID(
+		ctx context.Context,
+		bookingID string,
+		updateFn func(showCancellation ShowCancellation) (ShowCancellation, error),
+	) (ShowCancellation, error)
+}
+
+type ShowTicketsRepository interface {
+	// FindByShowID returns confirmed tickets of not canceled bookings of the show.
+	FindByShowID(ctx context.Context, showID string) ([]Ticket, error)
+}
+
+type ShowCancellationProcessManager struct {
+	commandBus  *cqrs.CommandBus
+	repository  ShowCancellationRepository
+	ticketsRepo ShowTicketsRepository
+}
+
+func NewShowCancellationProcessManager(
+	commandBus *cqrs.CommandBus,
+	repository ShowCancellationRepository,
+	ticketsRepo ShowTicketsRepository,
+) *ShowCancellationProcessManager {
+	return &ShowCancellationProcessManager{
+		commandBus:  commandBus,
+		repository:  repository,
+		ticketsRepo: ticketsRepo,
+	}
+}
+
+func (s ShowCancellationProcessManager) OnShowCanceled(ctx context.Context, event *ShowCanceled_v1) error {
+	tickets, err := s.ticketsRepo.FindByShowID(ctx, event.ShowID)
+	if err != nil {
+		return fmt.Errorf("could not find tickets of show %s: %w", event.ShowID, err)
+	}
+
+	ticketIDs := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		ticketIDs = append(ticketIDs, ticket.TicketID)
+	}
+
+	sc, err := NewShowCancellation(event.ShowID, event.Header.PublishedAt, ticketIDs)
+	if err != nil {
+		return err
+	}
+
+	// on re-delivery, the already stored cancellation is used
+	stored, err := s.repository.Add(ctx, *sc)
+	if err != nil {
+		return err
+	}
+
+	for _, ticketID := range stored.TicketIDs {
+		if stored.IsRefunded(ticketID) {
+			continue
+		}
+
+		if err := s.sendRefund(ctx, ticketID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// OnTicketBookingConfirmed refunds tickets confirmed after the show was canceled.
+func (s ShowCancellationProcessManager) OnTicketBookingConfirmed(ctx context.Context, event *TicketBookingConfirmed_v1) error {
+	sc, err := s.repository.UpdateByBookingID(
+		ctx,
+		event.BookingID,
+		func(showCancellation ShowCancellation) (ShowCancellation, error) {
+			showCancellation.AddTicket(event.TicketID)
+			return showCancellation, nil
+		},
+	)
+	if errors.Is(err, ErrNotFound) {
+		// ticket is not from a canceled show
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if sc.IsRefunded(event.TicketID) {
+		return nil
+	}
+
+	// on re-delivery, the refund is sent again and de-duplicated by its idempotency key
+	return s.sendRefund(ctx, event.TicketID)
+}
+
+func (s ShowCancellationProcessManager) OnTicketRefunded(ctx context.Context, event *TicketRefunded_v1) error {
+	return s.updateTicketShowCancellation(ctx, event.TicketID, func(showCancellation *ShowCancellation) {
+		showCancellation.MarkRefunded(event.TicketID, event.Header.PublishedAt)
+	})
+}
+
+func (s ShowCancellationProcessManager) OnTicketRefundFailed(ctx context.Context, event *TicketRefundFailed_v1) error {
+	return s.updateTicketShowCancellation(ctx, event.TicketID, func(showCancellation *ShowCancellation) {
+		showCancellation.MarkRefundFailed(event.TicketID, event.Header.PublishedAt)
+	})
+}
+
+func (s ShowCancellationProcessManager) updateTicketShowCancellation(
+	ctx context.Context,
+	ticketID string,
+	updateFn func(showCancellation *ShowCancellation),
+) error {
+	_, err := s.repository.UpdateByTicketID(
+		ctx,
+		ticketID,
+		func(showCancellation ShowCancellation) (ShowCancellation, error) {
+			updateFn(&showCancellation)
+			return showCancellation, nil
+		},
+	)
+	if errors.Is(err, ErrNotFound) {
+		// ticket is not from a canceled show
+		return nil
+	}
+
+	return err
+}
+
+func (s ShowCancellationProcessManager) sendRefund(ctx context.Context, ticketID string) error {
+	err := s.commandBus.Send(ctx, RefundTicket{
+		Header:   NewCommandHeaderWithIdempotencyKey(TicketRefundIdempotencyKey(ticketID)),
+		TicketID: ticketID,
+	})
+	if err != nil {
+		return fmt.Errorf("could not send refund for ticket %s: %w", ticketID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowCancellation(t *testing.T) {
+	now := time.Now()
+
+	sc, err := NewShowCancellation("show-1", now, []string{"ticket-1", "ticket-2"})
+	require.NoError(t, err)
+
+	sc.MarkRefunded("ticket-1", now)
+	sc.MarkRefunded("unknown", now)
+	assert.Equal(t, ShowCancellationStatusInProgress, sc.Status())
+
+	sc.MarkRefundFailed("ticket-2", now)
+	assert.Equal(t, ShowCancellationStatusCompleted, sc.Status(), "failed refunds should not block completion")
+	assert.Equal(t, []string{"ticket-2"}, sc.FailedTicketIDs)
+
+	// ticket confirmed after the show was canceled
+	sc.AddTicket("ticket-3")
+	sc.AddTicket("ticket-3")
+	assert.Equal(t, ShowCancellationStatusInProgress, sc.Status())
+	assert.Len(t, sc.TicketIDs, 3)
+
+	sc.MarkRefunded("ticket-3", now)
+	assert.Equal(t, ShowCancellationStatusCompleted, sc.Status())
+
+	// refund which failed before can succeed when requested again
+	sc.MarkRefunded("ticket-2", now)
+	assert.Empty(t, sc.FailedTicketIDs)
+	assert.ElementsMatch(t, []string{"ticket-1", "ticket-2", "ticket-3"}, sc.RefundedTicketIDs)
+}
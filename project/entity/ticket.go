@@ -1,8 +1,138 @@
 package entity
 
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	TicketStatusConfirmed = "confirmed"
+	TicketStatusPrinted   = "printed"
+	TicketStatusReceipted = "receipted"
+	TicketStatusRefunding = "refunding"
+	TicketStatusRefunded  = "refunded"
+	TicketStatusCanceled  = "canceled"
+)
+
+// Ticket is a confirmed ticket. Its status changes only through its methods, which reject illegal transitions
+// with ErrIllegalTicketTransition. Refunded and canceled tickets are final.
+//
+// Refunds happen in two steps: StartRefund moves the ticket to refunding, so it's not printed or canceled
+// while its money goes back, and Refund moves it to refunded once the payment was refunded.
+//
+// Tickets are printed and their receipts are issued concurrently, so they can happen in any order.
+// A ticket is receipted once its receipt is issued, even if it was not printed yet.
 type Ticket struct {
 	TicketID      string `json:"ticket_id" db:"ticket_id"`
-	PriceAmount   string `json:"price_amount" db:"price_amount"`
-	PriceCurrency string `json:"price_currency" db:"price_currency"`
+	BookingID     string `json:"booking_id" db:"booking_id"`
+	Price         Money  `json:"price" db:"-"`
 	CustomerEmail string `json:"customer_email" db:"customer_email"`
+	Status        string `json:"status" db:"status"`
+
+	PrintedAt       *time.Time `json:"printed_at,omitempty" db:"printed_at"`
+	ReceiptIssuedAt *time.Time `json:"receipt_issued_at,omitempty" db:"receipt_issued_at"`
+	ReceiptNumber   string     `json:"receipt_number,omitempty" db:"receipt_number"`
+	RefundedAt      *time.Time `json:"refunded_at,omitempty" db:"refunded_at"`
+
+	// DeletedAt is set when the ticket booking was canceled.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// NewTicket returns a ticket which was just confirmed.
+func NewTicket(ticketID string, bookingID string, price Money, customerEmail string) Ticket {
+	return Ticket{
+		TicketID:      ticketID,
+		BookingID:     bookingID,
+		Price:         price,
+		CustomerEmail: customerEmail,
+		Status:        TicketStatusConfirmed,
+	}
+}
+
+// Print marks the ticket as printed. Printing already printed ticket does nothing.
+func (t *Ticket) Print(printedAt time.Time) error {
+	if err := t.checkNotRefunded("print"); err != nil {
+		return err
+	}
+	if t.PrintedAt != nil {
+		return nil
+	}
+
+	t.PrintedAt = &printedAt
+	if t.Status == TicketStatusConfirmed {
+		t.Status = TicketStatusPrinted
+	}
+
+	return nil
+}
+
+// IssueReceipt marks the ticket as receipted. Issuing a receipt of already receipted ticket does nothing.
+func (t *Ticket) IssueReceipt(receiptNumber string, issuedAt time.Time) error {
+	if err := t.checkNotRefunded("issue receipt of"); err != nil {
+		return err
+	}
+	if t.ReceiptIssuedAt != nil {
+		return nil
+	}
+
+	t.ReceiptIssuedAt = &issuedAt
+	t.ReceiptNumber = receiptNumber
+	t.Status = TicketStatusReceipted
+
+	return nil
+}
+
+// StartRefund marks the ticket as being refunded. Starting refund of a ticket which is already being refunded
+// or refunded does nothing.
+func (t *Ticket) StartRefund() error {
+	if t.Status == TicketStatusRefunding || t.Status == TicketStatusRefunded {
+		return nil
+	}
+	if err := t.checkNotRefunded("refund"); err != nil {
+		return err
+	}
+
+	t.Status = TicketStatusRefunding
+
+	return nil
+}
+
+// Refund marks the ticket as refunded once its payment was refunded. Only tickets being refunded can be refunded,
+// refunding already refunded ticket does nothing.
+func (t *Ticket) Refund(refundedAt time.Time) error {
+	if t.Status == TicketStatusRefunded {
+		return nil
+	}
+	if t.Status != TicketStatusRefunding {
+		return fmt.Errorf("%w: can't refund %s ticket %s, its refund was not started", ErrIllegalTicketTransition, t.Status, t.TicketID)
+	}
+
+	t.RefundedAt = &refundedAt
+	t.Status = TicketStatusRefunded
+
+	return nil
+}
+
+// Cancel marks the ticket booking as canceled. Canceling already canceled ticket does nothing.
+func (t *Ticket) Cancel(canceledAt time.Time) error {
+	if t.Status == TicketStatusCanceled {
+		return nil
+	}
+	if err := t.checkNotRefunded("cancel"); err != nil {
+		return err
+	}
+
+	t.DeletedAt = &canceledAt
+	t.Status = TicketStatusCanceled
+
+	return nil
+}
+
+// checkNotRefunded rejects changes of final tickets and of tickets which are being refunded.
+func (t Ticket) checkNotRefunded(action string) error {
+	if t.Status == TicketStatusRefunding || t.Status == TicketStatusRefunded || t.Status == TicketStatusCanceled {
+		return fmt.Errorf("%w: can't %s %s ticket %s", ErrIllegalTicketTransition, action, t.Status, t.TicketID)
+	}
+
+	return nil
 }
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+const (
+	TicketRefundStatusPending   = "pending"
+	TicketRefundStatusCompleted = "completed"
+	TicketRefundStatusFailed    = "failed"
+)
+
+type TicketRefund struct {
+	TicketID      string     `json:"ticket_id" db:"ticket_id"`
+	Status        string     `json:"status" db:"status"`
+	FailureReason string     `json:"failure_reason,omitempty" db:"failure_reason"`
+	RequestedAt   time.Time  `json:"requested_at" db:"requested_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// TicketRefundIdempotencyKey returns the key used for all refund requests of the ticket,
+// so the receipt is voided and the payment is refunded only once.
+func TicketRefundIdempotencyKey(ticketID string) string {
+	return "ticket-refund-" + ticketID
+}
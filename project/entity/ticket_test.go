@@ -0,0 +1,121 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicket_transitions(t *testing.T) {
+	now := time.Now()
+
+	actions := map[string]func(*Ticket) error{
+		"print":         func(t *Ticket) error { return t.Print(now) },
+		"issue_receipt": func(t *Ticket) error { return t.IssueReceipt("receipt-1", now) },
+		"start_refund":  func(t *Ticket) error { return t.StartRefund() },
+		"refund":        func(t *Ticket) error { return t.Refund(now) },
+		"cancel":        func(t *Ticket) error { return t.Cancel(now) },
+	}
+
+	// expected status after each action, empty status means the transition is illegal
+	testCases := []struct {
+		Status   string
+		Expected map[string]string
+	}{
+		{
+			Status: TicketStatusConfirmed,
+			Expected: map[string]string{
+				"print":         TicketStatusPrinted,
+				"issue_receipt": TicketStatusReceipted,
+				"start_refund":  TicketStatusRefunding,
+				"refund":        "",
+				"cancel":        TicketStatusCanceled,
+			},
+		},
+		{
+			Status: TicketStatusPrinted,
+			Expected: map[string]string{
+				"print":         TicketStatusPrinted,
+				"issue_receipt": TicketStatusReceipted,
+				"start_refund":  TicketStatusRefunding,
+				"refund":        "",
+				"cancel":        TicketStatusCanceled,
+			},
+		},
+		{
+			Status: TicketStatusReceipted,
+			Expected: map[string]string{
+				"print":         TicketStatusReceipted,
+				"issue_receipt": TicketStatusReceipted,
+				"start_refund":  TicketStatusRefunding,
+				"refund":        "",
+				"cancel":        TicketStatusCanceled,
+			},
+		},
+		{
+			Status: TicketStatusRefunding,
+			Expected: map[string]string{
+				"print":         "",
+				"issue_receipt": "",
+				"start_refund":  TicketStatusRefunding,
+				"refund":        TicketStatusRefunded,
+				"cancel":        "",
+			},
+		},
+		{
+			Status: TicketStatusRefunded,
+			Expected: map[string]string{
+				"print":         "",
+				"issue_receipt": "",
+				"start_refund":  TicketStatusRefunded,
+				"refund":        TicketStatusRefunded,
+				"cancel":        "",
+			},
+		},
+		{
+			Status: TicketStatusCanceled,
+			Expected: map[string]string{
+				"print":         "",
+				"issue_receipt": "",
+				"start_refund":  "",
+				"refund":        "",
+				"cancel":        TicketStatusCanceled,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		for action, expected := range tc.Expected {
+			t.Run(tc.Status+"/"+action, func(t *testing.T) {
+				ticket := NewTicket("ticket-1", "booking-1", Money{Amount: decimal.NewFromInt(50), Currency: "USD"}, "foo@bar.com")
+				ticket.Status = tc.Status
+
+				err := actions[action](&ticket)
+				if expected == "" {
+					assert.ErrorIs(t, err, ErrIllegalTicketTransition)
+					assert.Equal(t, tc.Status, ticket.Status, "status should not change")
+					return
+				}
+
+				assert.NoError(t, err)
+				assert.Equal(t, expected, ticket.Status)
+			})
+		}
+	}
+}
+
+func TestTicket_refund(t *testing.T) {
+	now := time.Now()
+	ticket := NewTicket("ticket-1", "booking-1", Money{Amount: decimal.NewFromInt(50), Currency: "USD"}, "foo@bar.com")
+
+	assert.NoError(t, ticket.Print(now))
+	assert.NoError(t, ticket.StartRefund())
+	assert.Nil(t, ticket.RefundedAt, "ticket should not be refunded before its payment is refunded")
+
+	assert.NoError(t, ticket.Refund(now))
+	assert.Equal(t, TicketStatusRefunded, ticket.Status)
+	assert.Equal(t, &now, ticket.RefundedAt)
+	assert.NotNil(t, ticket.PrintedAt)
+}
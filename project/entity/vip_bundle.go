@@ -6,10 +6,25 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/google/uuid"
 )
 
+const (
+	VipBundleStepInboundFlight = "inbound_flight"
+	VipBundleStepReturnFlight  = "return_flight"
+	VipBundleStepTaxi          = "taxi"
+)
+
+// vipBundleStepTimeouts are the deadlines of steps waiting for external services, which may never respond.
+// The deadline of a flight step covers booking of all its alternative flights.
+var vipBundleStepTimeouts = map[string]time.Duration{
+	VipBundleStepInboundFlight: 10 * time.Minute,
+	VipBundleStepReturnFlight:  10 * time.Minute,
+	VipBundleStepTaxi:          10 * time.Minute,
+}
+
 type VipBundle struct {
 	VipBundleID string `json:"vip_bundle_id"`
 
@@ -17,12 +32,20 @@ type VipBundle struct {
 	CustomerEmail   string     `json:"customer_email"`
 	NumberOfTickets int        `json:"number_of_tickets"`
 	ShowId          string     `json:"show_id"`
+	PromoCode       string     `json:"promo_code,omitempty"`
 	BookingMadeAt   *time.Time `json:"booking_made_at"`
 
 	TicketIDs []string `json:"ticket_ids"`
 
 	Passengers []string `json:"passengers"`
 
+	// InboundFlightID and ReturnFlightID are the flights being booked, they change to the next alternative
+	// when booking of the flight fails.
+	AlternativeInboundFlightIDs []string `json:"alternative_inbound_flight_ids,omitempty"`
+	AlternativeReturnFlightIDs  []string `json:"alternative_return_flight_ids,omitempty"`
+
+	FlightAttempts []VipBundleFlightAttempt `json:"flight_attempts,omitempty"`
+
 	InboundFlightID         string     `json:"inbound_flight_id"`
 	InboundFlightBookedAt   *time.Time `json:"inbound_flight_booked_at"`
 	InboundFlightTicketsIDs []string   `json:"inbound_flight_tickets_ids"`
@@ -34,8 +57,10 @@ type VipBundle struct {
 	TaxiBookedAt  *time.Time `json:"taxi_booked_at"`
 	TaxiBookingID *string    `json:"taxi_booking_id"`
 
-	IsFinalized bool `json:"finalized"`
-	Failed      bool `json:"failed"`
+	IsFinalized   bool       `json:"finalized"`
+	Failed        bool       `json:"failed"`
+	FailedAt      *time.Time `json:"failed_at"`
+	FailureReason string     `json:"failure_reason"`
 }
 
 func NewVipBundle(
@@ -47,6 +72,9 @@ func NewVipBundle(
 	passengers []string,
 	inboundFlightID string,
 	returnFlightID string,
+	alternativeInboundFlightIDs []string,
+	alternativeReturnFlightIDs []string,
+	promoCode string,
 ) (*VipBundle, error) {
 	if vipBundleID == "" {
 		return nil, fmt.Errorf("vip bundle id must be set")
@@ -73,6 +101,21 @@ func NewVipBundle(
 		return nil, fmt.Errorf("return flight id must be set")
 	}
 
+	// attempts are matched with flights by their IDs, so all of them must be different
+	flightIDs := map[string]struct{}{}
+	for _, flightID := range append(
+		append([]string{inboundFlightID, returnFlightID}, alternativeInboundFlightIDs...),
+		alternativeReturnFlightIDs...,
+	) {
+		if flightID == "" {
+			return nil, fmt.Errorf("alternative flight id must be set")
+		}
+		if _, ok := flightIDs[flightID]; ok {
+			return nil, fmt.Errorf("flight %s is requested more than once", flightID)
+		}
+		flightIDs[flightID] = struct{}{}
+	}
+
 	return &VipBundle{
 		VipBundleID:     vipBundleID,
 		BookingID:       bookingID,
@@ -82,9 +125,26 @@ func NewVipBundle(
 		Passengers:      passengers,
 		InboundFlightID: inboundFlightID,
 		ReturnFlightID:  returnFlightID,
+		PromoCode:       promoCode,
+
+		AlternativeInboundFlightIDs: alternativeInboundFlightIDs,
+		AlternativeReturnFlightIDs:  alternativeReturnFlightIDs,
 	}, nil
 }
 
+func (vb VipBundle) IsStepCompleted(step string) bool {
+	switch step {
+	case VipBundleStepInboundFlight:
+		return vb.InboundFlightBookedAt != nil
+	case VipBundleStepReturnFlight:
+		return vb.ReturnFlightBookedAt != nil
+	case VipBundleStepTaxi:
+		return vb.TaxiBookedAt != nil
+	default:
+		return false
+	}
+}
+
 type VipBundleRepository interface {
 	Add(ctx context.Context, vipBundle VipBundle) error
 	Get(ctx context.Context, vipBundleID string) (VipBundle, error)
@@ -103,20 +163,27 @@ type VipBundleRepository interface {
 	) (VipBundle, error)
 }
 
+type EventScheduler interface {
+	Schedule(ctx context.Context, event Event, publishAt time.Time) error
+}
+
 type VipBundleProcessManager struct {
 	commandBus *cqrs.CommandBus
 	eventBus   *cqrs.EventBus
+	scheduler  EventScheduler
 	repository VipBundleRepository
 }
 
 func NewVipBundleProcessManager(
 	commandBus *cqrs.CommandBus,
 	eventBus *cqrs.EventBus,
+	scheduler EventScheduler,
 	repository VipBundleRepository,
 ) *VipBundleProcessManager {
 	return &VipBundleProcessManager{
 		commandBus: commandBus,
 		eventBus:   eventBus,
+		scheduler:  scheduler,
 		repository: repository,
 	}
 }
@@ -128,10 +195,12 @@ func (v VipBundleProcessManager) OnVipBundleInitialized(ctx context.Context, eve
 	}
 
 	return v.commandBus.Send(ctx, BookShowTickets{
+		Header:          NewCommandHeader(),
 		BookingID:       vb.BookingID,
 		CustomerEmail:   vb.CustomerEmail,
 		NumberOfTickets: vb.NumberOfTickets,
 		ShowId:          vb.ShowId,
+		PromoCode:       vb.PromoCode,
 	})
 }
 
@@ -141,6 +210,7 @@ func (v VipBundleProcessManager) OnBookingMade(ctx context.Context, event *Booki
 		event.BookingID,
 		func(vipBundle VipBundle) (VipBundle, error) {
 			vipBundle.BookingMadeAt = &event.Header.PublishedAt
+			vipBundle.startFlightAttempt(FlightDirectionInbound, vipBundle.InboundFlightID, time.Now().UTC())
 			return vipBundle, nil
 		},
 	)
@@ -153,13 +223,11 @@ func (v VipBundleProcessManager) OnBookingMade(ctx context.Context, event *Booki
 		return err
 	}
 
-	return v.commandBus.Send(ctx, BookFlight{
-		CustomerEmail:  vb.CustomerEmail,
-		FlightID:       vb.InboundFlightID,
-		Passengers:     vb.Passengers,
-		ReferenceID:    vb.VipBundleID,
-		IdempotencyKey: uuid.NewString(),
-	})
+	if _, err := v.scheduleStepDeadline(ctx, vb.VipBundleID, VipBundleStepInboundFlight); err != nil {
+		return err
+	}
+
+	return v.bookFlight(ctx, vb, vb.InboundFlightID)
 }
 
 func (v VipBundleProcessManager) OnTicketBookingConfirmed(ctx context.Context, event *TicketBookingConfirmed_v1) error {
@@ -192,7 +260,7 @@ func (v VipBundleProcessManager) OnBookingFailed(ctx context.Context, event *Boo
 		return err
 	}
 
-	return v.rollbackProcess(ctx, vb.VipBundleID)
+	return v.rollbackProcess(ctx, vb.VipBundleID, event.FailureReason)
 }
 
 func (v VipBundleProcessManager) OnFlightBooked(ctx context.Context, event *FlightBooked_v1) error {
@@ -208,6 +276,11 @@ func (v VipBundleProcessManager) OnFlightBooked(ctx context.Context, event *Flig
 				vipBundle.ReturnFlightBookedAt = &event.Header.PublishedAt
 				vipBundle.ReturnFlightTicketsIDs = event.TicketIDs
 			}
+			vipBundle.completeFlightAttempt(event.FlightID, event.Header.PublishedAt)
+
+			if !vipBundle.Failed && vipBundle.InboundFlightBookedAt != nil && vipBundle.ReturnFlightBookedAt == nil {
+				vipBundle.startFlightAttempt(FlightDirectionReturn, vipBundle.ReturnFlightID, time.Now().UTC())
+			}
 
 			return vipBundle, nil
 		},
@@ -216,22 +289,37 @@ func (v VipBundleProcessManager) OnFlightBooked(ctx context.Context, event *Flig
 		return err
 	}
 
+	if vb.Failed {
+		// the flight was booked after the bundle was rolled back
+		return v.commandBus.Send(ctx, CancelFlightTickets{
+			Header:          NewCommandHeader(),
+			FlightTicketIDs: event.TicketIDs,
+		})
+	}
+
 	switch {
 	case vb.InboundFlightBookedAt != nil && vb.ReturnFlightBookedAt == nil:
-		return v.commandBus.Send(ctx, BookFlight{
-			CustomerEmail:  vb.CustomerEmail,
-			FlightID:       vb.ReturnFlightID,
-			Passengers:     vb.Passengers,
-			ReferenceID:    vb.VipBundleID,
-			IdempotencyKey: uuid.NewString(),
-		})
+		if _, err := v.scheduleStepDeadline(ctx, vb.VipBundleID, VipBundleStepReturnFlight); err != nil {
+			return err
+		}
+
+		return v.bookFlight(ctx, vb, vb.ReturnFlightID)
 	case vb.InboundFlightBookedAt != nil && vb.ReturnFlightBookedAt != nil:
+		deadline, err := v.scheduleStepDeadline(ctx, vb.VipBundleID, VipBundleStepTaxi)
+		if err != nil {
+			return err
+		}
+
+		// the bundle is rolled back after the deadline, so the taxi must not be booked later
+		header := NewCommandHeaderWithIdempotencyKey(uuid.NewString())
+		header.ExpiresAt = &deadline
+
 		return v.commandBus.Send(ctx, BookTaxi{
+			Header:             header,
 			CustomerEmail:      vb.CustomerEmail,
 			CustomerName:       vb.Passengers[0],
 			NumberOfPassengers: vb.NumberOfTickets,
 			ReferenceID:        vb.VipBundleID,
-			IdempotencyKey:     uuid.NewString(),
 		})
 	default:
 		return fmt.Errorf(
@@ -242,8 +330,48 @@ func (v VipBundleProcessManager) OnFlightBooked(ctx context.Context, event *Flig
 	}
 }
 
+// OnFlightBookingFailed books the next alternative flight, the bundle is rolled back
+// only when booking of all alternatives failed.
 func (v VipBundleProcessManager) OnFlightBookingFailed(ctx context.Context, event *FlightBookingFailed_v1) error {
-	return v.rollbackProcess(ctx, event.ReferenceID)
+	vb, err := v.repository.UpdateByID(
+		ctx,
+		event.ReferenceID,
+		func(vb VipBundle) (VipBundle, error) {
+			if !vb.IsFinalized {
+				vb.failFlightAttempt(event.FlightID, event.FailureReason, time.Now().UTC())
+			}
+
+			return vb, nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if vb.IsFinalized {
+		return nil
+	}
+
+	attempt := vb.lastFlightAttempt(event.FlightID)
+	if attempt == nil {
+		// bundles created before flight attempts were recorded have no alternatives
+		return v.rollbackProcess(ctx, vb.VipBundleID, event.FailureReason)
+	}
+
+	switch attempt.Status {
+	case FlightAttemptStatusPending:
+		// the next alternative, it's booked again on re-delivery, which is deduplicated by the idempotency key
+		return v.bookFlight(ctx, vb, attempt.FlightID)
+	case FlightAttemptStatusFailed:
+		return v.rollbackProcess(
+			ctx,
+			vb.VipBundleID,
+			fmt.Sprintf("booking of all %s flights failed: %s", attempt.Direction, event.FailureReason),
+		)
+	default:
+		// re-delivery, an alternative was already booked
+		return nil
+	}
 }
 
 func (v VipBundleProcessManager) OnTaxiBooked(ctx context.Context, event *TaxiBooked_v1) error {
@@ -263,6 +391,12 @@ func (v VipBundleProcessManager) OnTaxiBooked(ctx context.Context, event *TaxiBo
 		return err
 	}
 
+	if vb.Failed {
+		// taxi bookings can't be canceled, the booking is only recorded
+		log.FromContext(ctx).WithField("taxi_booking_id", event.TaxiBookingID).Warn("Taxi was booked after the VIP bundle was rolled back")
+		return nil
+	}
+
 	return v.eventBus.Publish(ctx, VipBundleFinalized_v1{
 		Header:      NewEventHeader(),
 		VipBundleID: vb.VipBundleID,
@@ -270,15 +404,91 @@ func (v VipBundleProcessManager) OnTaxiBooked(ctx context.Context, event *TaxiBo
 }
 
 func (v VipBundleProcessManager) OnTaxiBookingFailed(ctx context.Context, event *TaxiBookingFailed_v1) error {
-	return v.rollbackProcess(ctx, event.ReferenceID)
+	return v.rollbackProcess(ctx, event.ReferenceID, event.FailureReason)
+}
+
+// bookFlight books the flight of the bundle. The command expires at the deadline of the flight's step,
+// the bundle is rolled back after it, so the flight must not be booked later.
+func (v VipBundleProcessManager) bookFlight(ctx context.Context, vb VipBundle, flightID string) error {
+	// the key is the same for each flight of the bundle, so the flight is not booked twice when the command is re-sent
+	idempotencyKey := uuid.NewSHA1(uuid.NameSpaceOID, []byte(vb.VipBundleID+"/"+flightID)).String()
+
+	header := NewCommandHeaderWithIdempotencyKey(idempotencyKey)
+	header.ExpiresAt = vb.flightStepDeadline(flightID)
+
+	return v.commandBus.Send(ctx, BookFlight{
+		Header:        header,
+		CustomerEmail: vb.CustomerEmail,
+		FlightID:      flightID,
+		Passengers:    vb.Passengers,
+		ReferenceID:   vb.VipBundleID,
+	})
+}
+
+// OnVipBundleStepTimedOut rolls back the bundle when the step didn't complete until its deadline.
+func (v VipBundleProcessManager) OnVipBundleStepTimedOut(ctx context.Context, event *InternalVipBundleStepTimedOut) error {
+	vb, err := v.repository.Get(ctx, event.VipBundleID)
+	if err != nil {
+		return err
+	}
+
+	if vb.IsFinalized || vb.IsStepCompleted(event.Step) {
+		return nil
+	}
+
+	return v.rollbackProcess(
+		ctx,
+		vb.VipBundleID,
+		fmt.Sprintf("timeout: %s step was not completed until %s", event.Step, event.Deadline.Format(time.RFC3339)),
+	)
+}
+
+// scheduleStepDeadline schedules the timeout of the step, which is ignored when the step completes in time,
+// and returns its deadline.
+func (v VipBundleProcessManager) scheduleStepDeadline(ctx context.Context, vipBundleID string, step string) (time.Time, error) {
+	deadline := time.Now().UTC().Add(vipBundleStepTimeouts[step])
+
+	err := v.scheduler.Schedule(ctx, InternalVipBundleStepTimedOut{
+		Header:      NewEventHeader(),
+		VipBundleID: vipBundleID,
+		Step:        step,
+		Deadline:    deadline,
+	}, deadline)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not schedule deadline of %s step: %w", step, err)
+	}
+
+	return deadline, nil
 }
 
-func (v VipBundleProcessManager) rollbackProcess(ctx context.Context, vipBundleID string) error {
-	vb, err := v.repository.Get(ctx, vipBundleID)
+func (v VipBundleProcessManager) rollbackProcess(ctx context.Context, vipBundleID string, failureReason string) error {
+	// the bundle is marked as failed first, so steps completed later are compensated by their handlers
+	vb, err := v.repository.UpdateByID(
+		ctx,
+		vipBundleID,
+		func(vb VipBundle) (VipBundle, error) {
+			if vb.IsFinalized {
+				// re-delivery keeps the original failure, completed bundles are not rolled back
+				return vb, nil
+			}
+
+			now := time.Now().UTC()
+
+			vb.IsFinalized = true
+			vb.Failed = true
+			vb.FailedAt = &now
+			vb.FailureReason = failureReason
+			return vb, nil
+		},
+	)
 	if err != nil {
 		return err
 	}
 
+	if !vb.Failed {
+		return nil
+	}
+
 	if vb.BookingMadeAt != nil {
 		if err := v.rollbackTickets(ctx, vb); err != nil {
 			return err
@@ -286,6 +496,7 @@ func (v VipBundleProcessManager) rollbackProcess(ctx context.Context, vipBundleI
 	}
 	if vb.InboundFlightBookedAt != nil {
 		if err := v.commandBus.Send(ctx, CancelFlightTickets{
+			Header:          NewCommandHeader(),
 			FlightTicketIDs: vb.InboundFlightTicketsIDs,
 		}); err != nil {
 			return err
@@ -293,23 +504,14 @@ func (v VipBundleProcessManager) rollbackProcess(ctx context.Context, vipBundleI
 	}
 	if vb.ReturnFlightBookedAt != nil {
 		if err := v.commandBus.Send(ctx, CancelFlightTickets{
+			Header:          NewCommandHeader(),
 			FlightTicketIDs: vb.ReturnFlightTicketsIDs,
 		}); err != nil {
 			return err
 		}
 	}
 
-	_, err = v.repository.UpdateByID(
-		ctx,
-		vb.VipBundleID,
-		func(vb VipBundle) (VipBundle, error) {
-			vb.IsFinalized = true
-			vb.Failed = true
-			return vb, nil
-		},
-	)
-
-	return err
+	return nil
 }
 
 func (v VipBundleProcessManager) rollbackTickets(ctx context.Context, vb VipBundle) error {
@@ -325,7 +527,7 @@ func (v VipBundleProcessManager) rollbackTickets(ctx context.Context, vb VipBund
 
 	for _, ticketID := range vb.TicketIDs {
 		if err := v.commandBus.Send(ctx, RefundTicket{
-			Header:   NewEventHeader(),
+			Header:   NewCommandHeaderWithIdempotencyKey(TicketRefundIdempotencyKey(ticketID)),
 			TicketID: ticketID,
 		}); err != nil {
 			return err
@@ -0,0 +1,135 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	FlightDirectionInbound = "inbound"
+	FlightDirectionReturn  = "return"
+
+	FlightAttemptStatusPending = "pending"
+	FlightAttemptStatusBooked  = "booked"
+	FlightAttemptStatusFailed  = "failed"
+)
+
+// VipBundleFlightAttempt is one attempt to book a flight of the bundle.
+// Flights are tried in order, starting with the requested one and continuing with its alternatives.
+type VipBundleFlightAttempt struct {
+	Direction     string     `json:"direction"`
+	FlightID      string     `json:"flight_id"`
+	Status        string     `json:"status"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+}
+
+// startFlightAttempt records the attempt to book the flight. Starting an already started attempt does nothing.
+func (vb *VipBundle) startFlightAttempt(direction string, flightID string, startedAt time.Time) {
+	if vb.flightAttempt(flightID) != nil {
+		return
+	}
+
+	vb.FlightAttempts = append(vb.FlightAttempts, VipBundleFlightAttempt{
+		Direction: direction,
+		FlightID:  flightID,
+		Status:    FlightAttemptStatusPending,
+		StartedAt: startedAt,
+	})
+}
+
+func (vb *VipBundle) completeFlightAttempt(flightID string, bookedAt time.Time) {
+	attempt := vb.flightAttempt(flightID)
+	if attempt == nil || attempt.Status != FlightAttemptStatusPending {
+		return
+	}
+
+	attempt.Status = FlightAttemptStatusBooked
+	attempt.FinishedAt = &bookedAt
+}
+
+// failFlightAttempt records the failed attempt and starts the attempt to book the next alternative flight,
+// if there is any left. Failing an already failed attempt does nothing.
+func (vb *VipBundle) failFlightAttempt(flightID string, failureReason string, failedAt time.Time) {
+	attempt := vb.flightAttempt(flightID)
+	if attempt == nil || attempt.Status != FlightAttemptStatusPending {
+		return
+	}
+
+	attempt.Status = FlightAttemptStatusFailed
+	attempt.FinishedAt = &failedAt
+	attempt.FailureReason = failureReason
+
+	direction := attempt.Direction
+	alternatives := vb.AlternativeInboundFlightIDs
+	if direction == FlightDirectionReturn {
+		alternatives = vb.AlternativeReturnFlightIDs
+	}
+
+	for _, alternativeID := range alternatives {
+		if vb.flightAttempt(alternativeID) != nil {
+			continue
+		}
+
+		if direction == FlightDirectionInbound {
+			vb.InboundFlightID = alternativeID
+		} else {
+			vb.ReturnFlightID = alternativeID
+		}
+		vb.startFlightAttempt(direction, alternativeID, failedAt)
+
+		return
+	}
+}
+
+// lastFlightAttempt returns the latest attempt to book a flight in the direction of the flight,
+// it's nil when the flight was never attempted.
+func (vb VipBundle) lastFlightAttempt(flightID string) *VipBundleFlightAttempt {
+	attempt := vb.flightAttempt(flightID)
+	if attempt == nil {
+		return nil
+	}
+
+	var last VipBundleFlightAttempt
+	for _, a := range vb.FlightAttempts {
+		if a.Direction == attempt.Direction {
+			last = a
+		}
+	}
+
+	return &last
+}
+
+// flightStepDeadline returns the deadline of the step booking the flight. The step's deadline is scheduled
+// when its first attempt starts, so it covers all alternative flights of the direction.
+// Bundles created before flight attempts were recorded have no deadline.
+func (vb VipBundle) flightStepDeadline(flightID string) *time.Time {
+	attempt := vb.flightAttempt(flightID)
+	if attempt == nil {
+		return nil
+	}
+
+	step := VipBundleStepInboundFlight
+	if attempt.Direction == FlightDirectionReturn {
+		step = VipBundleStepReturnFlight
+	}
+
+	for _, a := range vb.FlightAttempts {
+		if a.Direction == attempt.Direction {
+			deadline := a.StartedAt.Add(vipBundleStepTimeouts[step])
+			return &deadline
+		}
+	}
+
+	return nil
+}
+
+func (vb *VipBundle) flightAttempt(flightID string) *VipBundleFlightAttempt {
+	for i := range vb.FlightAttempts {
+		if vb.FlightAttempts[i].FlightID == flightID {
+			return &vb.FlightAttempts[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVipBundle_flightStepDeadline(t *testing.T) {
+	startedAt := time.Now().UTC()
+
+	vb, err := NewVipBundle(
+		"vip-bundle-1",
+		"booking-1",
+		"foo@bar.com",
+		1,
+		"show-1",
+		[]string{"John Doe"},
+		"inbound-1",
+		"return-1",
+		[]string{"inbound-2"},
+		nil,
+		"",
+	)
+	require.NoError(t, err)
+
+	assert.Nil(t, vb.flightStepDeadline("inbound-1"), "flight which was not attempted has no deadline")
+
+	vb.startFlightAttempt(FlightDirectionInbound, "inbound-1", startedAt)
+	vb.failFlightAttempt("inbound-1", "sold out", startedAt.Add(time.Minute))
+
+	// the alternative flight is booked until the deadline of the first attempt
+	expected := startedAt.Add(vipBundleStepTimeouts[VipBundleStepInboundFlight])
+	assert.Equal(t, &expected, vb.flightStepDeadline("inbound-1"))
+	assert.Equal(t, &expected, vb.flightStepDeadline("inbound-2"))
+
+	returnStartedAt := startedAt.Add(2 * time.Minute)
+	vb.startFlightAttempt(FlightDirectionReturn, "return-1", returnStartedAt)
+
+	expected = returnStartedAt.Add(vipBundleStepTimeouts[VipBundleStepReturnFlight])
+	assert.Equal(t, &expected, vb.flightStepDeadline("return-1"))
+}
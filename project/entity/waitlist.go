@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+const (
+	WaitlistEntryStatusWaiting  = "waiting"
+	WaitlistEntryStatusOffered  = "offered"
+	WaitlistEntryStatusAccepted = "accepted"
+	WaitlistEntryStatusExpired  = "expired"
+)
+
+// WaitlistEntry is a customer waiting for seats of a sold-out show.
+// When seats free up, the customer is offered a seat hold, which must be confirmed before it expires.
+type WaitlistEntry struct {
+	EntryID         string    `json:"entry_id" db:"entry_id"`
+	ShowID          string    `json:"show_id" db:"show_id"`
+	NumberOfTickets int       `json:"number_of_tickets" db:"number_of_tickets"`
+	CustomerEmail   string    `json:"customer_email" db:"customer_email"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+
+	// Status is derived from the offered seat hold.
+	Status         string     `json:"status" db:"status"`
+	HoldID         *string    `json:"hold_id" db:"hold_id"`
+	OfferedAt      *time.Time `json:"offered_at" db:"offered_at"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at" db:"offer_expires_at"`
+	BookingID      *string    `json:"booking_id" db:"booking_id"`
+}
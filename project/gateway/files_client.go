@@ -7,6 +7,8 @@ import (
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/clients"
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+
+	"tickets/entity"
 )
 
 type FilesClient struct {
@@ -43,7 +45,7 @@ func (c FilesClient) DownloadFile(ctx context.Context, fileID string) (string, e
 	}
 
 	if resp.StatusCode() == http.StatusNotFound {
-		return "", nil
+		return "", fmt.Errorf("file %s: %w", fileID, entity.ErrNotFound)
 	}
 	if resp.StatusCode() != http.StatusOK {
 		return "", fmt.Errorf("unexpected status code while getting file %s: %d", fileID, resp.StatusCode())
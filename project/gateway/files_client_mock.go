@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"sync"
+
+	"tickets/entity"
 )
 
 type FilesMock struct {
@@ -34,7 +36,7 @@ func (c *FilesMock) DownloadFile(ctx context.Context, fileID string) (string, er
 
 	fileContent, ok := c.files[fileID]
 	if !ok {
-		return "", fmt.Errorf("file %s not found", fileID)
+		return "", fmt.Errorf("file %s: %w", fileID, entity.ErrNotFound)
 	}
 
 	return fileContent, nil
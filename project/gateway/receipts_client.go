@@ -25,7 +25,7 @@ func (c ReceiptsClient) IssueReceipt(ctx context.Context, request entity.IssueRe
 	body := receipts.PutReceiptsJSONRequestBody{
 		TicketId: request.TicketID,
 		Price: receipts.Money{
-			MoneyAmount:   request.Price.Amount,
+			MoneyAmount:   request.Price.AmountString(),
 			MoneyCurrency: request.Price.Currency,
 		},
 		IdempotencyKey: &request.IdempotencyKey,
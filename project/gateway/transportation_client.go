@@ -33,7 +33,7 @@ func (c TransportationClient) PutFlightTicketsWithResponse(ctx context.Context,
 		FlightId:       FlightID,
 		PassengerNames: bookFlight.Passengers,
 		ReferenceId:    bookFlight.ReferenceID,
-		IdempotencyKey: bookFlight.IdempotencyKey,
+		IdempotencyKey: bookFlight.Header.IdempotencyKey,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to flight tickets booking: %w", err)
@@ -61,7 +61,7 @@ func (c TransportationClient) PutTaxiBookingWithResponse(ctx context.Context, bo
 		NumberOfPassengers: bookTaxi.NumberOfPassengers,
 		PassengerName:      bookTaxi.CustomerName,
 		ReferenceId:        bookTaxi.ReferenceID,
-		IdempotencyKey:     bookTaxi.IdempotencyKey,
+		IdempotencyKey:     bookTaxi.Header.IdempotencyKey,
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to taxi booking: %w", err)
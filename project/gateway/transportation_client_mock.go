@@ -13,6 +13,9 @@ type TransportationMock struct {
 	BookedFlightTickets    map[string]entity.BookFlight
 	BookedTaxiBookings     map[string]entity.BookTaxi
 	CancelledFlightTickets map[string]entity.CancelFlightTickets
+
+	// SoldOutFlightIDs are flights which can't be booked.
+	SoldOutFlightIDs []string
 }
 
 func (c *TransportationMock) PutFlightTicketsWithResponse(ctx context.Context, bookFlight entity.BookFlight) ([]string, error) {
@@ -23,6 +26,12 @@ func (c *TransportationMock) PutFlightTicketsWithResponse(ctx context.Context, b
 		c.BookedFlightTickets = make(map[string]entity.BookFlight)
 	}
 
+	for _, soldOutFlightID := range c.SoldOutFlightIDs {
+		if bookFlight.FlightID == soldOutFlightID {
+			return nil, entity.ErrConflict
+		}
+	}
+
 	c.BookedFlightTickets[bookFlight.FlightID] = bookFlight
 
 	return []string{"mocked-flight-ticket-id"}, nil
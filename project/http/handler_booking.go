@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -11,6 +14,40 @@ import (
 	"tickets/entity"
 )
 
+const (
+	bookingStatusActive   = "active"
+	bookingStatusCanceled = "canceled"
+)
+
+type bookingResponse struct {
+	BookingID       string                  `json:"booking_id"`
+	CustomerEmail   string                  `json:"customer_email"`
+	NumberOfTickets int                     `json:"number_of_tickets"`
+	Status          string                  `json:"status"`
+	CanceledAt      *time.Time              `json:"canceled_at,omitempty"`
+	Seats           []entity.Seat           `json:"seats,omitempty"`
+	Quote           []entity.TicketQuote    `json:"quote,omitempty"`
+	Discount        *entity.Discount        `json:"discount,omitempty"`
+	Show            bookingShowResponse     `json:"show"`
+	Tickets         []bookingTicketResponse `json:"tickets"`
+}
+
+type bookingShowResponse struct {
+	ShowID    string    `json:"show_id"`
+	Title     string    `json:"title"`
+	Venue     string    `json:"venue"`
+	StartTime time.Time `json:"start_time"`
+}
+
+type bookingTicketResponse struct {
+	TicketID      string       `json:"ticket_id"`
+	Price         entity.Money `json:"price"`
+	ReceiptNumber string       `json:"receipt_number,omitempty"`
+	FileURL       string       `json:"file_url,omitempty"`
+	Refunded      bool         `json:"refunded"`
+	RefundedAt    *time.Time   `json:"refunded_at,omitempty"`
+}
+
 func (s Server) PostBookTickets(c echo.Context) error {
 	var request postBookTicketsRequest
 	err := c.Bind(&request)
@@ -18,23 +55,51 @@ func (s Server) PostBookTickets(c echo.Context) error {
 		return err
 	}
 
+	if len(request.Seats) > 0 {
+		if len(request.Seats) != request.NumberOfTickets {
+			return echo.NewHTTPError(http.StatusBadRequest, "number of seats must be equal to the number of tickets")
+		}
+		if hasDuplicatedSeats(request.Seats) {
+			return echo.NewHTTPError(http.StatusBadRequest, "seats must be unique")
+		}
+	}
+
 	booking := entity.Booking{
 		BookingID:       uuid.NewString(),
 		ShowID:          request.ShowID,
 		NumberOfTickets: request.NumberOfTickets,
 		CustomerEmail:   request.CustomerEmail,
+		Seats:           request.Seats,
+		PriceCategory:   request.PriceCategory,
+		PromoCode:       request.PromoCode,
 	}
 
 	show, err := s.showsRepo.Get(c.Request().Context(), request.ShowID)
 	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "show not found")
+		}
 		return fmt.Errorf("could not get show: %w", err)
 	}
+	if show.CanceledAt != nil {
+		return echo.NewHTTPError(http.StatusConflict, "show is canceled")
+	}
 
-	err = s.bookingsRepo.Store(c.Request().Context(), booking, show.NumberOfTickets)
+	err = s.bookingsRepo.Store(c.Request().Context(), booking)
 	if err != nil {
+		if errors.Is(err, entity.ErrShowCanceled) {
+			// the show was canceled after it was checked above
+			return echo.NewHTTPError(http.StatusConflict, "show is canceled")
+		}
 		if errors.Is(err, entity.ErrNoAvailableTickets) {
 			return echo.NewHTTPError(http.StatusBadRequest, "not enough seats available")
 		}
+		if errors.Is(err, entity.ErrSeatNotAvailable) {
+			return echo.NewHTTPError(http.StatusConflict, "selected seats are not available")
+		}
+		if errors.Is(err, entity.ErrUnknownPriceCategory) || errors.Is(err, entity.ErrInvalidPromoCode) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 
 		return fmt.Errorf("could not store booking: %w", err)
 	}
@@ -43,3 +108,115 @@ func (s Server) PostBookTickets(c echo.Context) error {
 		BookingID: booking.BookingID,
 	})
 }
+
+func hasDuplicatedSeats(seats []entity.Seat) bool {
+	unique := make(map[entity.Seat]struct{}, len(seats))
+	for _, seat := range seats {
+		if _, ok := unique[seat]; ok {
+			return true
+		}
+		unique[seat] = struct{}{}
+	}
+
+	return false
+}
+
+// GetBooking returns the booking to its customer, who must prove the ownership by providing the booking's email.
+func (s Server) GetBooking(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	bookingID := c.Param("id")
+	if _, err := uuid.Parse(bookingID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid booking id")
+	}
+
+	customerEmail := c.QueryParam("customer_email")
+	if customerEmail == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "customer_email is required")
+	}
+
+	booking, err := s.bookingsRepo.Get(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "booking not found")
+		}
+		return fmt.Errorf("could not get booking: %w", err)
+	}
+
+	// the same response as for a missing booking, so it's not possible to check which bookings exist
+	if !strings.EqualFold(booking.CustomerEmail, customerEmail) {
+		return echo.NewHTTPError(http.StatusNotFound, "booking not found")
+	}
+
+	show, err := s.showsRepo.Get(ctx, booking.ShowID)
+	if err != nil {
+		return fmt.Errorf("could not get show: %w", err)
+	}
+
+	// tickets are added to the read model asynchronously, so it may not exist yet
+	opsBooking, err := s.opsBookingReadModel.ReservationReadModel(ctx, bookingID)
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		return fmt.Errorf("could not get booking read model: %w", err)
+	}
+
+	response := bookingResponse{
+		BookingID:       booking.BookingID,
+		CustomerEmail:   booking.CustomerEmail,
+		NumberOfTickets: booking.NumberOfTickets,
+		Status:          bookingStatusActive,
+		CanceledAt:      booking.CanceledAt,
+		Seats:           booking.Seats,
+		Quote:           booking.Quote,
+		Discount:        booking.Discount,
+		Show: bookingShowResponse{
+			ShowID:    show.ShowID,
+			Title:     show.Title,
+			Venue:     show.Venue,
+			StartTime: show.StartTime,
+		},
+		Tickets: make([]bookingTicketResponse, 0, len(opsBooking.Tickets)),
+	}
+	if booking.CanceledAt != nil {
+		response.Status = bookingStatusCanceled
+	}
+
+	for ticketID, ticket := range opsBooking.Tickets {
+		ticketResp := bookingTicketResponse{
+			TicketID:      ticketID,
+			Price:         ticket.Price,
+			ReceiptNumber: ticket.ReceiptNumber,
+			Refunded:      !ticket.RefundedAt.IsZero(),
+		}
+		if ticketResp.Refunded {
+			refundedAt := ticket.RefundedAt
+			ticketResp.RefundedAt = &refundedAt
+		} else if ticket.PrintedFileName != "" {
+			ticketResp.FileURL = "/tickets/" + ticketID + "/file"
+		}
+
+		response.Tickets = append(response.Tickets, ticketResp)
+	}
+	sort.Slice(response.Tickets, func(i, j int) bool {
+		return response.Tickets[i].TicketID < response.Tickets[j].TicketID
+	})
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s Server) DeleteBooking(c echo.Context) error {
+	bookingID := c.Param("id")
+	if _, err := uuid.Parse(bookingID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid booking id")
+	}
+
+	err := s.bookingsRepo.Cancel(c.Request().Context(), bookingID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "booking not found")
+		}
+
+		return fmt.Errorf("could not cancel booking: %w", err)
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
@@ -1,36 +1,141 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+
+	"tickets/entity"
 )
 
+const maxOpsBookingsPageSize = 500
+
+type getOpsBookingsResponse struct {
+	Bookings   []entity.OpsBooking `json:"bookings"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
 func (s Server) GetOpsTickets(c echo.Context) error {
-	receiptIssueDate := c.QueryParam("receipt_issue_date")
+	filter := entity.OpsBookingsFilter{
+		CustomerEmail:    c.QueryParam("customer_email"),
+		ShowID:           c.QueryParam("show_id"),
+		ReceiptIssueDate: c.QueryParam("receipt_issue_date"),
+		Sort:             c.QueryParam("sort"),
+		Cursor:           c.QueryParam("cursor"),
+	}
 
-	if receiptIssueDate != "" {
-		_, err := time.Parse("2006-01-02", receiptIssueDate)
+	if filter.ReceiptIssueDate != "" {
+		_, err := time.Parse(time.DateOnly, filter.ReceiptIssueDate)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "invalid receipt_issue_date format, expected RFC3339 date: ", err.Error())
 		}
 	}
+	if filter.ShowID != "" {
+		if _, err := uuid.Parse(filter.ShowID); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid show_id")
+		}
+	}
+	switch filter.Sort {
+	case "", entity.OpsBookingsSortBookedAtAsc, entity.OpsBookingsSortBookedAtDesc:
+	default:
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			fmt.Sprintf("invalid sort, expected %s or %s", entity.OpsBookingsSortBookedAtAsc, entity.OpsBookingsSortBookedAtDesc),
+		)
+	}
+
+	var err error
+	if filter.BookedFrom, err = parseTimeQueryParam(c, "booked_from"); err != nil {
+		return err
+	}
+	if filter.BookedTo, err = parseTimeQueryParam(c, "booked_to"); err != nil {
+		return err
+	}
+	if filter.HasRefunds, err = parseBoolQueryParam(c, "has_refunds"); err != nil {
+		return err
+	}
+	if filter.Printed, err = parseBoolQueryParam(c, "printed"); err != nil {
+		return err
+	}
+
+	// clients which don't paginate get all bookings as a plain array, as before pagination was added
+	paginated := c.QueryParam("limit") != "" || filter.Cursor != ""
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 || filter.Limit > maxOpsBookingsPageSize {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxOpsBookingsPageSize))
+		}
+	}
+
+	if !paginated {
+		bookings, err := s.allOpsBookings(c, filter)
+		if err != nil {
+			return err
+		}
 
-	allReservations, err := s.opsBookingReadModel.AllReservations(receiptIssueDate)
+		return c.JSON(http.StatusOK, bookings)
+	}
+
+	bookings, nextCursor, err := s.opsBookingReadModel.AllReservations(c.Request().Context(), filter)
 	if err != nil {
+		if errors.Is(err, entity.ErrInvalidCursor) {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+		}
 		return fmt.Errorf("failed to get all reservations: %w", err)
 	}
 
-	return c.JSON(http.StatusOK, allReservations)
+	return c.JSON(http.StatusOK, getOpsBookingsResponse{
+		Bookings:   bookings,
+		NextCursor: nextCursor,
+	})
+}
+
+func (s Server) allOpsBookings(c echo.Context, filter entity.OpsBookingsFilter) ([]entity.OpsBooking, error) {
+	filter.Limit = maxOpsBookingsPageSize
+
+	all := []entity.OpsBooking{}
+	for {
+		bookings, nextCursor, err := s.opsBookingReadModel.AllReservations(c.Request().Context(), filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get all reservations: %w", err)
+		}
+
+		all = append(all, bookings...)
+		if nextCursor == "" {
+			return all, nil
+		}
+		filter.Cursor = nextCursor
+	}
 }
 
 func (s Server) GetOpsTicket(c echo.Context) error {
 	reservation, err := s.opsBookingReadModel.ReservationReadModel(c.Request().Context(), c.Param("id"))
 	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "booking not found")
+		}
 		return fmt.Errorf("failed to get reservation: %w", err)
 	}
 
 	return c.JSON(http.StatusOK, reservation)
 }
+
+func parseBoolQueryParam(c echo.Context, name string) (*bool, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s, expected true or false", name))
+	}
+
+	return &b, nil
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"tickets/entity"
+)
+
+const (
+	opsBookingsStreamKeepAliveInterval = 15 * time.Second
+
+	// Responses are buffered by the body dump middleware, so the stream is closed periodically.
+	// Browsers reconnect automatically and resume from the Last-Event-ID.
+	opsBookingsStreamMaxDuration = 10 * time.Minute
+
+	opsBookingsStreamRetry = 3 * time.Second
+)
+
+// GetOpsBookingsStream streams updated ops bookings as Server-Sent Events.
+// Event ID is the position of the update in the stream, so clients can resume with Last-Event-ID.
+func (s Server) GetOpsBookingsStream(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	bookingID := c.QueryParam("booking_id")
+	if bookingID != "" {
+		if _, err := uuid.Parse(bookingID); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid booking_id")
+		}
+	}
+
+	// subscribe before reading the position, so no update is lost in between
+	updates, unsubscribe := s.opsBookingUpdatesFeed.Subscribe()
+	defer unsubscribe()
+
+	position := c.Request().Header.Get("Last-Event-ID")
+	if position == "" {
+		var err error
+		position, err = s.opsBookingReadModel.UpdatesPosition(ctx)
+		if err != nil {
+			return fmt.Errorf("could not get ops bookings updates position: %w", err)
+		}
+	}
+
+	// missed updates are read before headers are sent, so an invalid Last-Event-ID can be rejected
+	bookingUpdates, err := s.opsBookingReadModel.UpdatedSince(ctx, position, bookingID)
+	if errors.Is(err, entity.ErrInvalidCursor) {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid Last-Event-ID")
+	} else if err != nil {
+		return fmt.Errorf("could not get updated ops bookings: %w", err)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(res, "retry: %d\n\n", opsBookingsStreamRetry.Milliseconds()); err != nil {
+		return nil
+	}
+	res.Flush()
+
+	keepAlive := time.NewTicker(opsBookingsStreamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	maxDuration := time.NewTimer(opsBookingsStreamMaxDuration)
+	defer maxDuration.Stop()
+
+	for {
+		for _, update := range bookingUpdates {
+			if err := writeOpsBookingEvent(res, update); err != nil {
+				return nil
+			}
+			position = update.Position
+		}
+		if len(bookingUpdates) > 0 {
+			res.Flush()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-maxDuration.C:
+			return nil
+		case <-updates:
+			// updates of other bookings are filtered out by the query
+		case <-keepAlive.C:
+			// updates hidden by still running older transactions are returned by the next query
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+
+		bookingUpdates, err = s.opsBookingReadModel.UpdatedSince(ctx, position, bookingID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// headers are already sent, so the error can't be returned to the client
+			log.FromContext(ctx).Errorf("could not get updated ops bookings: %s", err)
+			return nil
+		}
+	}
+}
+
+func writeOpsBookingEvent(res *echo.Response, update entity.OpsBookingUpdate) error {
+	payload, err := json.Marshal(update.Booking)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(
+		res,
+		"id: %s\nevent: booking_updated\ndata: %s\n\n",
+		update.Position,
+		payload,
+	)
+	return err
+}
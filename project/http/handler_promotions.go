@@ -0,0 +1,106 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+
+	"tickets/entity"
+)
+
+type postPromotionRequest struct {
+	Code         string           `json:"code"`
+	DiscountType string           `json:"discount_type"`
+	Percentage   *decimal.Decimal `json:"percentage"`
+	Amount       *entity.Money    `json:"amount"`
+	ShowID       *string          `json:"show_id"`
+	ValidFrom    *time.Time       `json:"valid_from"`
+	ValidUntil   *time.Time       `json:"valid_until"`
+	UsageLimit   *int             `json:"usage_limit"`
+}
+
+type promotionResponse struct {
+	Code         string           `json:"code"`
+	DiscountType string           `json:"discount_type"`
+	Percentage   *decimal.Decimal `json:"percentage,omitempty"`
+	Amount       *entity.Money    `json:"amount,omitempty"`
+	ShowID       *string          `json:"show_id,omitempty"`
+	ValidFrom    *time.Time       `json:"valid_from,omitempty"`
+	ValidUntil   *time.Time       `json:"valid_until,omitempty"`
+	UsageLimit   *int             `json:"usage_limit,omitempty"`
+	UsedCount    int              `json:"used_count"`
+}
+
+func (s Server) PostPromotions(c echo.Context) error {
+	var request postPromotionRequest
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	promotion := entity.Promotion{
+		Code: request.Code,
+		Discount: entity.Discount{
+			PromoCode:  request.Code,
+			Type:       request.DiscountType,
+			Percentage: request.Percentage,
+			Amount:     request.Amount,
+		},
+		ShowID:     request.ShowID,
+		ValidFrom:  request.ValidFrom,
+		ValidUntil: request.ValidUntil,
+		UsageLimit: request.UsageLimit,
+	}
+	if err := promotion.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if promotion.ShowID != nil {
+		_, err := s.showsRepo.Get(c.Request().Context(), *promotion.ShowID)
+		if err != nil {
+			if errors.Is(err, entity.ErrNotFound) {
+				return echo.NewHTTPError(http.StatusNotFound, "show not found")
+			}
+			return fmt.Errorf("could not get show: %w", err)
+		}
+	}
+
+	err := s.promotionsRepo.Store(c.Request().Context(), promotion)
+	if err != nil {
+		if errors.Is(err, entity.ErrConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "promotion with this code already exists")
+		}
+		return fmt.Errorf("could not store promotion: %w", err)
+	}
+
+	return c.JSON(http.StatusCreated, newPromotionResponse(promotion))
+}
+
+func (s Server) GetPromotion(c echo.Context) error {
+	promotion, err := s.promotionsRepo.Get(c.Request().Context(), c.Param("code"))
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "promotion not found")
+		}
+		return fmt.Errorf("could not get promotion: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, newPromotionResponse(promotion))
+}
+
+func newPromotionResponse(promotion entity.Promotion) promotionResponse {
+	return promotionResponse{
+		Code:         promotion.Code,
+		DiscountType: promotion.Discount.Type,
+		Percentage:   promotion.Discount.Percentage,
+		Amount:       promotion.Discount.Amount,
+		ShowID:       promotion.ShowID,
+		ValidFrom:    promotion.ValidFrom,
+		ValidUntil:   promotion.ValidUntil,
+		UsageLimit:   promotion.UsageLimit,
+		UsedCount:    promotion.UsedCount,
+	}
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"tickets/entity"
+)
+
+type postSeatHoldsRequest struct {
+	ShowID          string `json:"show_id"`
+	NumberOfTickets int    `json:"number_of_tickets"`
+	CustomerEmail   string `json:"customer_email"`
+}
+
+type postSeatHoldsResponse struct {
+	HoldID    string    `json:"hold_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PostSeatHolds reserves seats for the seat hold TTL. The hold must be confirmed to become a booking.
+func (s Server) PostSeatHolds(c echo.Context) error {
+	var request postSeatHoldsRequest
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	show, err := s.showsRepo.Get(c.Request().Context(), request.ShowID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "show not found")
+		}
+		return fmt.Errorf("could not get show: %w", err)
+	}
+	if show.CanceledAt != nil {
+		return echo.NewHTTPError(http.StatusConflict, "show is canceled")
+	}
+
+	hold := entity.SeatHold{
+		HoldID:          uuid.NewString(),
+		ShowID:          request.ShowID,
+		NumberOfTickets: request.NumberOfTickets,
+		CustomerEmail:   request.CustomerEmail,
+		ExpiresAt:       time.Now().UTC().Add(s.seatHoldTTL).Truncate(time.Microsecond),
+	}
+
+	err = s.bookingsRepo.Hold(c.Request().Context(), hold)
+	if err != nil {
+		if errors.Is(err, entity.ErrShowCanceled) {
+			return echo.NewHTTPError(http.StatusConflict, "show is canceled")
+		}
+		if errors.Is(err, entity.ErrNoAvailableTickets) {
+			return echo.NewHTTPError(http.StatusBadRequest, "not enough seats available")
+		}
+		return fmt.Errorf("could not hold seats: %w", err)
+	}
+
+	return c.JSON(http.StatusCreated, postSeatHoldsResponse{
+		HoldID:    hold.HoldID,
+		ExpiresAt: hold.ExpiresAt,
+	})
+}
+
+func (s Server) ConfirmSeatHold(c echo.Context) error {
+	holdID := c.Param("id")
+	if _, err := uuid.Parse(holdID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid hold id")
+	}
+
+	booking, err := s.bookingsRepo.ConfirmHold(c.Request().Context(), holdID, uuid.NewString())
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, "seat hold not found")
+		case errors.Is(err, entity.ErrSeatHoldExpired):
+			return echo.NewHTTPError(http.StatusGone, "seat hold expired")
+		case errors.Is(err, entity.ErrShowCanceled):
+			return echo.NewHTTPError(http.StatusConflict, "show is canceled")
+		case errors.Is(err, entity.ErrUnknownPriceCategory):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return fmt.Errorf("could not confirm seat hold: %w", err)
+	}
+
+	return c.JSON(http.StatusCreated, postBookTicketsResponse{
+		BookingID: booking.BookingID,
+	})
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"tickets/entity"
+)
+
+type showSeatsResponse struct {
+	Seats []showSeatResponse `json:"seats"`
+}
+
+type showSeatResponse struct {
+	entity.Seat
+	Category  string `json:"category"`
+	Available bool   `json:"available"`
+}
+
+// PutShowSeatMap replaces the seat map of the show. The show's number of tickets becomes the number of seats.
+func (s Server) PutShowSeatMap(c echo.Context) error {
+	showID := c.Param("id")
+	if _, err := uuid.Parse(showID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid show id")
+	}
+
+	var request entity.SeatMap
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	seats, err := request.ShowSeats()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	show, err := s.showsRepo.Get(c.Request().Context(), showID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "show not found")
+		}
+		return fmt.Errorf("could not get show: %w", err)
+	}
+	if show.CanceledAt != nil {
+		return echo.NewHTTPError(http.StatusConflict, "show is canceled")
+	}
+
+	err = s.bookingsRepo.SetSeatMap(c.Request().Context(), showID, seats)
+	if err != nil {
+		if errors.Is(err, entity.ErrSeatMapInUse) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if errors.Is(err, entity.ErrShowCanceled) {
+			return echo.NewHTTPError(http.StatusConflict, "show is canceled")
+		}
+		return fmt.Errorf("could not set seat map: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, newShowSeatsResponse(seats))
+}
+
+func (s Server) GetShowSeats(c echo.Context) error {
+	showID := c.Param("id")
+	if _, err := uuid.Parse(showID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid show id")
+	}
+
+	seats, err := s.bookingsRepo.ShowSeats(c.Request().Context(), showID)
+	if err != nil {
+		return fmt.Errorf("could not get show seats: %w", err)
+	}
+	if len(seats) == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "show has no seat map")
+	}
+
+	return c.JSON(http.StatusOK, newShowSeatsResponse(seats))
+}
+
+func newShowSeatsResponse(seats []entity.ShowSeat) showSeatsResponse {
+	response := showSeatsResponse{
+		Seats: make([]showSeatResponse, 0, len(seats)),
+	}
+	for _, seat := range seats {
+		response.Seats = append(response.Seats, showSeatResponse{
+			Seat:      seat.Seat,
+			Category:  seat.Category,
+			Available: seat.Available(),
+		})
+	}
+
+	return response
+}
@@ -1,7 +1,10 @@
 package http
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,18 +13,59 @@ import (
 	"tickets/entity"
 )
 
+const maxShowsPageSize = 100
+
 type postShowsRequest struct {
 	DeadNationID    string    `json:"dead_nation_id"`
 	NumberOfTickets int       `json:"number_of_tickets"`
 	StartTime       time.Time `json:"start_time"`
 	Title           string    `json:"title"`
 	Venue           string    `json:"venue"`
+
+	PriceCategories []entity.PriceCategory `json:"price_categories"`
 }
 
 type postShowsResponse struct {
 	ShowID string `json:"show_id"`
 }
 
+type showResponse struct {
+	ShowID           string     `json:"show_id"`
+	DeadNationID     string     `json:"dead_nation_id"`
+	NumberOfTickets  int        `json:"number_of_tickets"`
+	AvailableTickets int        `json:"available_tickets"`
+	StartTime        time.Time  `json:"start_time"`
+	Title            string     `json:"title"`
+	Venue            string     `json:"venue"`
+	CanceledAt       *time.Time `json:"canceled_at,omitempty"`
+
+	PriceCategories []entity.PriceCategory `json:"price_categories,omitempty"`
+}
+
+type putShowRequest struct {
+	NumberOfTickets *int                    `json:"number_of_tickets"`
+	StartTime       *time.Time              `json:"start_time"`
+	Venue           *string                 `json:"venue"`
+	PriceCategories *[]entity.PriceCategory `json:"price_categories"`
+}
+
+type showCancellationResponse struct {
+	ShowID          string     `json:"show_id"`
+	Status          string     `json:"status"`
+	CanceledAt      time.Time  `json:"canceled_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	TicketsTotal    int        `json:"tickets_total"`
+	TicketsRefunded int        `json:"tickets_refunded"`
+
+	// FailedTicketIDs are tickets which can't be refunded, their refund failure reasons are available in GetTicketRefund.
+	FailedTicketIDs []string `json:"failed_ticket_ids"`
+}
+
+type getShowsResponse struct {
+	Shows      []showResponse `json:"shows"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
 func (s Server) PostShows(c echo.Context) error {
 	var request postShowsRequest
 	err := c.Bind(&request)
@@ -29,6 +73,10 @@ func (s Server) PostShows(c echo.Context) error {
 		return err
 	}
 
+	if err := entity.ValidatePriceCategories(request.PriceCategories); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	showID := uuid.NewString()
 	err = s.showsRepo.Store(c.Request().Context(), entity.Show{
 		ShowID:          showID,
@@ -37,6 +85,7 @@ func (s Server) PostShows(c echo.Context) error {
 		StartTime:       request.StartTime,
 		Title:           request.Title,
 		Venue:           request.Venue,
+		PriceCategories: request.PriceCategories,
 	})
 	if err != nil {
 		return err
@@ -46,3 +95,203 @@ func (s Server) PostShows(c echo.Context) error {
 		ShowID: showID,
 	})
 }
+
+func (s Server) GetShows(c echo.Context) error {
+	filter := entity.ShowsFilter{
+		Venue:  c.QueryParam("venue"),
+		Title:  c.QueryParam("title"),
+		Cursor: c.QueryParam("cursor"),
+	}
+
+	var err error
+	if filter.StartTimeFrom, err = parseTimeQueryParam(c, "start_time_from"); err != nil {
+		return err
+	}
+	if filter.StartTimeTo, err = parseTimeQueryParam(c, "start_time_to"); err != nil {
+		return err
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 || filter.Limit > maxShowsPageSize {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxShowsPageSize))
+		}
+	}
+
+	shows, nextCursor, err := s.showsRepo.List(c.Request().Context(), filter)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidCursor) {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+		}
+		return fmt.Errorf("could not list shows: %w", err)
+	}
+
+	response := getShowsResponse{
+		Shows:      make([]showResponse, 0, len(shows)),
+		NextCursor: nextCursor,
+	}
+	availableTickets, err := s.bookingsRepo.AvailableTickets(c.Request().Context(), shows...)
+	if err != nil {
+		return fmt.Errorf("could not get available tickets: %w", err)
+	}
+	for _, show := range shows {
+		response.Shows = append(response.Shows, newShowResponse(show, availableTickets[show.ShowID]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s Server) GetShow(c echo.Context) error {
+	showID := c.Param("id")
+	if _, err := uuid.Parse(showID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid show id")
+	}
+
+	show, err := s.showsRepo.Get(c.Request().Context(), showID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "show not found")
+		}
+		return fmt.Errorf("could not get show: %w", err)
+	}
+
+	response, err := s.getShowResponse(c, show)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s Server) PutShow(c echo.Context) error {
+	showID := c.Param("id")
+	if _, err := uuid.Parse(showID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid show id")
+	}
+
+	var request putShowRequest
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	if request.NumberOfTickets == nil && request.StartTime == nil && request.Venue == nil && request.PriceCategories == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "nothing to update")
+	}
+	if request.PriceCategories != nil {
+		if err := entity.ValidatePriceCategories(*request.PriceCategories); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	show, err := s.showsRepo.Update(c.Request().Context(), showID, entity.ShowUpdate{
+		NumberOfTickets: request.NumberOfTickets,
+		StartTime:       request.StartTime,
+		Venue:           request.Venue,
+		PriceCategories: request.PriceCategories,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, "show not found")
+		case errors.Is(err, entity.ErrShowCanceled):
+			return echo.NewHTTPError(http.StatusConflict, "show is canceled")
+		case errors.Is(err, entity.ErrCapacityBelowBookedSeats), errors.Is(err, entity.ErrCapacityDefinedBySeatMap):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return fmt.Errorf("could not update show: %w", err)
+	}
+
+	response, err := s.getShowResponse(c, show)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// CancelShow cancels the show, and refunds all of its confirmed tickets asynchronously.
+// The progress of refunds is available in GetShowCancellation.
+func (s Server) CancelShow(c echo.Context) error {
+	showID := c.Param("id")
+	if _, err := uuid.Parse(showID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid show id")
+	}
+
+	err := s.showsRepo.Cancel(c.Request().Context(), showID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "show not found")
+		}
+		return fmt.Errorf("could not cancel show: %w", err)
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (s Server) GetShowCancellation(c echo.Context) error {
+	showID := c.Param("id")
+	if _, err := uuid.Parse(showID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid show id")
+	}
+
+	sc, err := s.showCancellationsRepo.Get(c.Request().Context(), showID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			// the cancellation is created asynchronously, after the show is canceled
+			return echo.NewHTTPError(http.StatusNotFound, "show cancellation not found")
+		}
+		return fmt.Errorf("could not get show cancellation: %w", err)
+	}
+
+	failedTicketIDs := sc.FailedTicketIDs
+	if failedTicketIDs == nil {
+		failedTicketIDs = []string{}
+	}
+
+	return c.JSON(http.StatusOK, showCancellationResponse{
+		ShowID:          sc.ShowID,
+		Status:          sc.Status(),
+		CanceledAt:      sc.CanceledAt,
+		CompletedAt:     sc.CompletedAt,
+		TicketsTotal:    len(sc.TicketIDs),
+		TicketsRefunded: len(sc.RefundedTicketIDs),
+		FailedTicketIDs: failedTicketIDs,
+	})
+}
+
+// getShowResponse returns the response of a single show, listed shows get their available tickets at once.
+func (s Server) getShowResponse(c echo.Context, show entity.Show) (showResponse, error) {
+	availableTickets, err := s.bookingsRepo.AvailableTickets(c.Request().Context(), show)
+	if err != nil {
+		return showResponse{}, fmt.Errorf("could not get available tickets for show %s: %w", show.ShowID, err)
+	}
+
+	return newShowResponse(show, availableTickets[show.ShowID]), nil
+}
+
+func newShowResponse(show entity.Show, availableTickets int) showResponse {
+	return showResponse{
+		ShowID:           show.ShowID,
+		DeadNationID:     show.DeadNationID,
+		NumberOfTickets:  show.NumberOfTickets,
+		AvailableTickets: availableTickets,
+		StartTime:        show.StartTime,
+		Title:            show.Title,
+		Venue:            show.Venue,
+		CanceledAt:       show.CanceledAt,
+		PriceCategories:  show.PriceCategories,
+	}
+}
+
+func parseTimeQueryParam(c echo.Context, name string) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s format, expected RFC3339", name))
+	}
+
+	return &t, nil
+}
@@ -1,11 +1,10 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
-	"github.com/google/uuid"
-
 	"tickets/entity"
 
 	"github.com/labstack/echo/v4"
@@ -33,6 +32,14 @@ type postBookTicketsRequest struct {
 	ShowID          string `json:"show_id"`
 	NumberOfTickets int    `json:"number_of_tickets"`
 	CustomerEmail   string `json:"customer_email"`
+
+	// Seats are optional, the first available seats are booked when they are not selected.
+	Seats []entity.Seat `json:"seats"`
+
+	// PriceCategory is optional, the show's default category is used when it's not selected.
+	PriceCategory string `json:"price_category"`
+
+	PromoCode string `json:"promo_code"`
 }
 
 type postBookTicketsResponse struct {
@@ -46,46 +53,178 @@ func (s Server) PostTicketsStatus(c echo.Context) error {
 		return err
 	}
 
-	idempotencyKey := c.Request().Header.Get("Idempotency-Key")
+	idempotencyKey := c.Request().Header.Get(idempotencyKeyHeader)
 	if idempotencyKey == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "Idempotency-Key header is required")
 	}
 
+	// the whole batch is validated before assigning seats and publishing anything, so it's all-or-nothing
+	var confirmedTickets []entity.Ticket
+	bookings := make(map[string]*entity.Booking)
+	paidPrices := make(map[string]entity.Money, len(request.Tickets))
+	for _, ticket := range request.Tickets {
+		if err := ticket.Price.Validate(); err != nil {
+			return echo.NewHTTPError(
+				http.StatusBadRequest,
+				fmt.Sprintf("invalid price of ticket %s: %s", ticket.TicketID, err),
+			)
+		}
+
+		paidPrice, err := s.paidTicketPrice(c, bookings, ticket)
+		if err != nil {
+			return err
+		}
+		paidPrices[ticket.TicketID] = paidPrice
+
+		switch ticket.Status {
+		case "confirmed":
+			confirmedTickets = append(
+				confirmedTickets,
+				entity.NewTicket(ticket.TicketID, ticket.BookingID, ticket.Price, ticket.CustomerEmail),
+			)
+		case "canceled":
+		default:
+			return echo.NewHTTPError(
+				http.StatusBadRequest,
+				fmt.Sprintf("unknown status %q of ticket %s", ticket.Status, ticket.TicketID),
+			)
+		}
+	}
+
+	// prices quoted for seats are validated with the assigned seats, which are not assigned if any price is invalid;
+	// assigning is idempotent, so the same seats are assigned when the request is retried
+	seats, err := s.bookingsRepo.AssignTicketSeats(
+		c.Request().Context(),
+		confirmedTickets,
+		func(ticket entity.Ticket, seat *entity.Seat) error {
+			return s.validateQuotedTicketPrice(c, bookings, ticket, seat)
+		},
+	)
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not assign seats to tickets: %w", err)
+	}
+
+	ticketSeats := make(map[string]*entity.Seat, len(seats))
+	for i, seat := range seats {
+		ticketSeats[confirmedTickets[i].TicketID] = seat
+	}
+
+	events := make([]entity.Event, 0, len(request.Tickets))
 	for _, ticket := range request.Tickets {
-		if ticket.Status == "confirmed" {
+		switch ticket.Status {
+		case "confirmed":
 			event := entity.TicketBookingConfirmed_v1{
 				Header:        entity.NewEventHeaderWithIdempotencyKey(idempotencyKey + ticket.TicketID),
 				TicketID:      ticket.TicketID,
 				CustomerEmail: ticket.CustomerEmail,
-				Price:         ticket.Price,
 				BookingID:     ticket.BookingID,
+				Price:         paidPrices[ticket.TicketID],
+				Seat:          ticketSeats[ticket.TicketID],
 			}
-
-			err = s.eventbus.Publish(c.Request().Context(), event)
-			if err != nil {
-				return err
+			if !event.Price.Equal(ticket.Price) {
+				listPrice := ticket.Price
+				event.ListPrice = &listPrice
 			}
-		} else if ticket.Status == "canceled" {
-			event := entity.TicketBookingCanceled_v1{
+
+			events = append(events, event)
+		case "canceled":
+			events = append(events, entity.TicketBookingCanceled_v1{
 				Header:        entity.NewEventHeaderWithIdempotencyKey(idempotencyKey + ticket.TicketID),
 				TicketID:      ticket.TicketID,
 				CustomerEmail: ticket.CustomerEmail,
-				Price:         ticket.Price,
 				BookingID:     ticket.BookingID,
-			}
-
-			err = s.eventbus.Publish(c.Request().Context(), event)
-			if err != nil {
-				return err
-			}
-		} else {
-			return fmt.Errorf("unknown ticket status: %s", ticket.Status)
+				Price:         paidPrices[ticket.TicketID],
+			})
 		}
 	}
 
+	err = s.outboxEventPublisher.Publish(c.Request().Context(), events...)
+	if err != nil {
+		return fmt.Errorf("could not publish tickets status events: %w", err)
+	}
+
 	return c.NoContent(http.StatusOK)
 }
 
+// validateQuotedTicketPrice checks that the confirmed ticket's price is the price quoted when its booking was made.
+// Tickets of bookings which were not made in this service or are priced outside of it are not validated.
+func (s Server) validateQuotedTicketPrice(
+	c echo.Context,
+	bookings map[string]*entity.Booking,
+	ticket entity.Ticket,
+	seat *entity.Seat,
+) error {
+	booking, err := s.ticketBooking(c, bookings, ticket.BookingID)
+	if err != nil || booking == nil {
+		return err
+	}
+
+	quotedPrice, ok := booking.QuotedTicketPrice(seat)
+	if !ok {
+		return nil
+	}
+
+	if !ticket.Price.Equal(quotedPrice) {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			fmt.Sprintf(
+				"price %s of ticket %s doesn't match the quoted price %s",
+				ticket.Price, ticket.TicketID, quotedPrice,
+			),
+		)
+	}
+
+	return nil
+}
+
+// paidTicketPrice returns the ticket's price after the discount of its booking,
+// so the same price is stored, printed, receipted and refunded.
+// Tickets of bookings which were not made in this service are not discounted.
+func (s Server) paidTicketPrice(
+	c echo.Context,
+	bookings map[string]*entity.Booking,
+	ticket ticketStatusRequest,
+) (entity.Money, error) {
+	booking, err := s.ticketBooking(c, bookings, ticket.BookingID)
+	if err != nil || booking == nil {
+		return ticket.Price, err
+	}
+
+	price, err := booking.DiscountedTicketPrice(ticket.Price)
+	if err != nil {
+		return entity.Money{}, echo.NewHTTPError(
+			http.StatusBadRequest,
+			fmt.Sprintf("could not apply discount of booking %s to ticket %s: %s", booking.BookingID, ticket.TicketID, err),
+		)
+	}
+
+	return price, nil
+}
+
+// ticketBooking returns the booking of the ticket, it's nil for bookings which were not made in this service.
+// Bookings are cached, so each booking of the batch is read once.
+func (s Server) ticketBooking(c echo.Context, bookings map[string]*entity.Booking, bookingID string) (*entity.Booking, error) {
+	if booking, ok := bookings[bookingID]; ok {
+		return booking, nil
+	}
+
+	var booking *entity.Booking
+	b, err := s.bookingsRepo.Get(c.Request().Context(), bookingID)
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		return nil, fmt.Errorf("could not get booking %s: %w", bookingID, err)
+	}
+	if err == nil {
+		booking = &b
+	}
+	bookings[bookingID] = booking
+
+	return booking, nil
+}
+
 func (s Server) GetTickets(c echo.Context) error {
 	tickets, err := s.ticketsRepo.FindAll(c.Request().Context())
 	if err != nil {
@@ -97,26 +236,79 @@ func (s Server) GetTickets(c echo.Context) error {
 		response = append(response, ticketResponse{
 			TicketID:      ticket.TicketID,
 			CustomerEmail: ticket.CustomerEmail,
-			Price: entity.Money{
-				Amount:   ticket.PriceAmount,
-				Currency: ticket.PriceCurrency,
-			},
+			Price:         ticket.Price,
 		})
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
+func (s Server) GetTicketFile(c echo.Context) error {
+	ticketID := c.Param("id")
+
+	ticket, err := s.opsBookingReadModel.TicketReadModel(c.Request().Context(), ticketID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "ticket not found")
+		}
+		return fmt.Errorf("could not get ticket: %w", err)
+	}
+
+	if !ticket.RefundedAt.IsZero() {
+		return echo.NewHTTPError(http.StatusGone, "ticket was refunded")
+	}
+	if ticket.PrintedFileName == "" {
+		return echo.NewHTTPError(http.StatusNotFound, "ticket is not printed yet")
+	}
+
+	content, err := s.filesService.DownloadFile(c.Request().Context(), ticket.PrintedFileName)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "ticket file not found")
+		}
+		return fmt.Errorf("could not download ticket file: %w", err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", ticket.PrintedFileName))
+
+	return c.Blob(http.StatusOK, echo.MIMETextHTMLCharsetUTF8, []byte(content))
+}
+
 func (s Server) TicketRefund(c echo.Context) error {
+	ctx := c.Request().Context()
 	ticketID := c.Param("ticket_id")
 
-	err := s.commandBus.Send(c.Request().Context(), &entity.RefundTicket{
-		Header:   entity.NewEventHeaderWithIdempotencyKey(uuid.NewString()),
-		TicketID: ticketID,
-	})
+	ticket, err := s.ticketsRepo.Get(ctx, ticketID)
 	if err != nil {
-		return err
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "ticket not found")
+		}
+		return fmt.Errorf("could not get ticket: %w", err)
+	}
+	if ticket.Status == entity.TicketStatusCanceled {
+		return echo.NewHTTPError(http.StatusConflict, "ticket booking was canceled")
+	}
+
+	// pending refund is not sent again
+	refund, _, err := s.ticketRefundsRepo.Request(ctx, ticketID)
+	if err != nil {
+		return fmt.Errorf("could not request refund: %w", err)
+	}
+	if refund.Status == entity.TicketRefundStatusCompleted {
+		return echo.NewHTTPError(http.StatusConflict, "ticket is already refunded")
+	}
+
+	return c.JSON(http.StatusAccepted, refund)
+}
+
+func (s Server) GetTicketRefund(c echo.Context) error {
+	refund, err := s.ticketRefundsRepo.Get(c.Request().Context(), c.Param("ticket_id"))
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "refund not found")
+		}
+		return fmt.Errorf("could not get refund: %w", err)
 	}
 
-	return c.NoContent(http.StatusAccepted)
+	return c.JSON(http.StatusOK, refund)
 }
@@ -1,7 +1,10 @@
 package http
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -16,6 +19,11 @@ type vipBundleRequest struct {
 	Passengers      []string `json:"passengers"`
 	ReturnFlightID  string   `json:"return_flight_id"`
 	ShowID          string   `json:"show_id"`
+	PromoCode       string   `json:"promo_code"`
+
+	// alternatives are booked in order when booking of the flight fails
+	AlternativeInboundFlightIDs []string `json:"alternative_inbound_flight_ids"`
+	AlternativeReturnFlightIDs  []string `json:"alternative_return_flight_ids"`
 }
 
 type vipBundleResponse struct {
@@ -23,6 +31,32 @@ type vipBundleResponse struct {
 	VipBundleID string `json:"vip_bundle_id"`
 }
 
+const (
+	vipBundleStatusInProgress = "in_progress"
+	vipBundleStatusFinalized  = "finalized"
+	vipBundleStatusFailed     = "failed"
+
+	vipBundleStepCompleted = "completed"
+	vipBundleStepPending   = "pending"
+	vipBundleStepFailed    = "failed"
+)
+
+type vipBundleStatusResponse struct {
+	VipBundleID   string                  `json:"vip_bundle_id"`
+	BookingID     string                  `json:"booking_id"`
+	Status        string                  `json:"status"`
+	FailureReason string                  `json:"failure_reason,omitempty"`
+	Timeline      []vipBundleStepResponse `json:"timeline"`
+
+	FlightAttempts []entity.VipBundleFlightAttempt `json:"flight_attempts"`
+}
+
+type vipBundleStepResponse struct {
+	Step        string     `json:"step"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
 func (s Server) PostBookVipBundle(c echo.Context) error {
 	var r vipBundleRequest
 	err := c.Bind(&r)
@@ -30,18 +64,24 @@ func (s Server) PostBookVipBundle(c echo.Context) error {
 		return err
 	}
 
-	vb := entity.VipBundle{
-		VipBundleID:     uuid.NewString(),
-		BookingID:       uuid.NewString(),
-		ShowId:          r.ShowID,
-		ReturnFlightID:  r.ReturnFlightID,
-		CustomerEmail:   r.CustomerEmail,
-		NumberOfTickets: r.NumberOfTickets,
-		Passengers:      r.Passengers,
-		InboundFlightID: r.InboundFlightID,
+	vb, err := entity.NewVipBundle(
+		uuid.NewString(),
+		uuid.NewString(),
+		r.CustomerEmail,
+		r.NumberOfTickets,
+		r.ShowID,
+		r.Passengers,
+		r.InboundFlightID,
+		r.ReturnFlightID,
+		r.AlternativeInboundFlightIDs,
+		r.AlternativeReturnFlightIDs,
+		r.PromoCode,
+	)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	err = s.vipBundleRepo.Add(c.Request().Context(), vb)
+	err = s.vipBundleRepo.Add(c.Request().Context(), *vb)
 	if err != nil {
 		return err
 	}
@@ -50,5 +90,70 @@ func (s Server) PostBookVipBundle(c echo.Context) error {
 		VipBundleID: vb.VipBundleID,
 		BookingID:   vb.BookingID,
 	})
+}
+
+func (s Server) GetVipBundle(c echo.Context) error {
+	vipBundleID := c.Param("id")
+	if _, err := uuid.Parse(vipBundleID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid vip bundle id")
+	}
+
+	vb, err := s.vipBundleRepo.Get(c.Request().Context(), vipBundleID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "vip bundle not found")
+		}
+		return fmt.Errorf("could not get vip bundle: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, newVipBundleStatusResponse(vb))
+}
+
+func newVipBundleStatusResponse(vb entity.VipBundle) vipBundleStatusResponse {
+	response := vipBundleStatusResponse{
+		VipBundleID:   vb.VipBundleID,
+		BookingID:     vb.BookingID,
+		Status:        vipBundleStatusInProgress,
+		FailureReason: vb.FailureReason,
+
+		FlightAttempts: vb.FlightAttempts,
+	}
+	if response.FlightAttempts == nil {
+		response.FlightAttempts = []entity.VipBundleFlightAttempt{}
+	}
+
+	addStep := func(step string, completedAt *time.Time) {
+		status := vipBundleStepPending
+		if completedAt != nil {
+			status = vipBundleStepCompleted
+		}
+
+		response.Timeline = append(response.Timeline, vipBundleStepResponse{
+			Step:        step,
+			Status:      status,
+			CompletedAt: completedAt,
+		})
+	}
+
+	addStep("booking_made", vb.BookingMadeAt)
+	addStep("inbound_flight_booked", vb.InboundFlightBookedAt)
+	addStep("return_flight_booked", vb.ReturnFlightBookedAt)
+	addStep("taxi_booked", vb.TaxiBookedAt)
+
+	switch {
+	case vb.Failed:
+		response.Status = vipBundleStatusFailed
+		response.Timeline = append(response.Timeline, vipBundleStepResponse{
+			Step:        "failed",
+			Status:      vipBundleStepFailed,
+			CompletedAt: vb.FailedAt,
+		})
+	case vb.IsFinalized:
+		response.Status = vipBundleStatusFinalized
+		addStep("finalized", vb.TaxiBookedAt)
+	default:
+		addStep("finalized", nil)
+	}
 
+	return response
 }
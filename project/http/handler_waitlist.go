@@ -0,0 +1,120 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"tickets/entity"
+)
+
+type postWaitlistRequest struct {
+	NumberOfTickets int    `json:"number_of_tickets"`
+	CustomerEmail   string `json:"customer_email"`
+}
+
+type postWaitlistResponse struct {
+	EntryID string `json:"entry_id"`
+}
+
+// PostShowWaitlist adds the customer to the show's waitlist.
+// When seats free up, the customer is offered them for a limited time.
+func (s Server) PostShowWaitlist(c echo.Context) error {
+	showID := c.Param("id")
+	if _, err := uuid.Parse(showID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid show id")
+	}
+
+	var request postWaitlistRequest
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	show, err := s.showsRepo.Get(c.Request().Context(), showID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "show not found")
+		}
+		return fmt.Errorf("could not get show: %w", err)
+	}
+	if show.CanceledAt != nil {
+		return echo.NewHTTPError(http.StatusConflict, "show is canceled")
+	}
+	if request.NumberOfTickets > show.NumberOfTickets {
+		return echo.NewHTTPError(http.StatusBadRequest, "number of tickets exceeds show capacity")
+	}
+
+	entry := entity.WaitlistEntry{
+		EntryID:         uuid.NewString(),
+		ShowID:          showID,
+		NumberOfTickets: request.NumberOfTickets,
+		CustomerEmail:   request.CustomerEmail,
+	}
+
+	err = s.bookingsRepo.JoinWaitlist(c.Request().Context(), entry)
+	if err != nil {
+		return fmt.Errorf("could not join waitlist: %w", err)
+	}
+
+	return c.JSON(http.StatusCreated, postWaitlistResponse{
+		EntryID: entry.EntryID,
+	})
+}
+
+func (s Server) GetWaitlistEntry(c echo.Context) error {
+	entryID := c.Param("id")
+	if _, err := uuid.Parse(entryID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid waitlist entry id")
+	}
+
+	entry, err := s.bookingsRepo.GetWaitlistEntry(c.Request().Context(), entryID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "waitlist entry not found")
+		}
+		return fmt.Errorf("could not get waitlist entry: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, entry)
+}
+
+// AcceptWaitlistOffer books the seats offered to the waitlisted customer.
+func (s Server) AcceptWaitlistOffer(c echo.Context) error {
+	entryID := c.Param("id")
+	if _, err := uuid.Parse(entryID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid waitlist entry id")
+	}
+
+	entry, err := s.bookingsRepo.GetWaitlistEntry(c.Request().Context(), entryID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "waitlist entry not found")
+		}
+		return fmt.Errorf("could not get waitlist entry: %w", err)
+	}
+	if entry.HoldID == nil {
+		return echo.NewHTTPError(http.StatusConflict, "seats were not offered yet")
+	}
+
+	booking, err := s.bookingsRepo.ConfirmHold(c.Request().Context(), *entry.HoldID, uuid.NewString())
+	if err != nil {
+		if errors.Is(err, entity.ErrSeatHoldExpired) {
+			return echo.NewHTTPError(http.StatusGone, "offer expired")
+		}
+		if errors.Is(err, entity.ErrShowCanceled) {
+			return echo.NewHTTPError(http.StatusConflict, "show is canceled")
+		}
+		if errors.Is(err, entity.ErrUnknownPriceCategory) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return fmt.Errorf("could not confirm offered seat hold: %w", err)
+	}
+
+	return c.JSON(http.StatusCreated, postBookTicketsResponse{
+		BookingID: booking.BookingID,
+	})
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/labstack/echo/v4"
+
+	"tickets/entity"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// idempotencyMiddleware makes the endpoint safe to retry when the client sends an Idempotency-Key header.
+// The response of the first request is stored and replayed for every retry with the same key.
+// Requests without the header are processed as usual.
+func idempotencyMiddleware(repo IdempotencyKeysRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			idempotencyKey := c.Request().Header.Get(idempotencyKeyHeader)
+			if idempotencyKey == "" {
+				return next(c)
+			}
+			if len(idempotencyKey) > maxIdempotencyKeyLength {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("%s header is too long", idempotencyKeyHeader))
+			}
+
+			ctx := c.Request().Context()
+
+			requestHash, err := hashRequest(c)
+			if err != nil {
+				return err
+			}
+
+			storedResponse, err := repo.Begin(ctx, idempotencyKey, requestHash)
+			if errors.Is(err, entity.ErrIdempotencyKeyReused) {
+				return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+			}
+			if errors.Is(err, entity.ErrIdempotentRequestInFlight) {
+				return echo.NewHTTPError(http.StatusConflict, err.Error())
+			}
+			if err != nil {
+				return err
+			}
+
+			if storedResponse != nil {
+				return replayResponse(c, *storedResponse)
+			}
+
+			responseBody := new(bytes.Buffer)
+			c.Response().Writer = &bodyRecordingResponseWriter{
+				Writer:         io.MultiWriter(c.Response().Writer, responseBody),
+				ResponseWriter: c.Response().Writer,
+			}
+
+			if err := next(c); err != nil {
+				// the error needs to be rendered here, so the error response can be stored
+				c.Error(err)
+			}
+
+			if c.Response().Status >= http.StatusInternalServerError {
+				// server errors are not final, the client should be able to retry with the same key
+				if err := repo.Release(ctx, idempotencyKey); err != nil {
+					log.FromContext(ctx).WithError(err).Error("could not release idempotency key")
+				}
+				return nil
+			}
+
+			err = repo.Complete(ctx, idempotencyKey, entity.IdempotentResponse{
+				StatusCode:  c.Response().Status,
+				ContentType: c.Response().Header().Get(echo.HeaderContentType),
+				Body:        responseBody.Bytes(),
+			})
+			if err != nil {
+				// the request was already processed, so we can't return an error to the client
+				log.FromContext(ctx).WithError(err).Error("could not store idempotent response")
+			}
+
+			return nil
+		}
+	}
+}
+
+// hashRequest identifies the request by its method, path and body, so the same key can't be reused
+// for a different request.
+func hashRequest(c echo.Context) (string, error) {
+	var body []byte
+	if c.Request().Body != nil {
+		var err error
+		body, err = io.ReadAll(c.Request().Body)
+		if err != nil {
+			return "", fmt.Errorf("could not read request body: %w", err)
+		}
+	}
+	c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request().Method + " " + c.Request().URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func replayResponse(c echo.Context, response entity.IdempotentResponse) error {
+	c.Response().Header().Set(idempotentReplayedHeader, "true")
+	if response.ContentType != "" {
+		c.Response().Header().Set(echo.HeaderContentType, response.ContentType)
+	}
+
+	c.Response().WriteHeader(response.StatusCode)
+	_, err := c.Response().Write(response.Body)
+	return err
+}
+
+type bodyRecordingResponseWriter struct {
+	io.Writer
+	http.ResponseWriter
+}
+
+func (w *bodyRecordingResponseWriter) WriteHeader(code int) {
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *bodyRecordingResponseWriter) Write(b []byte) (int, error) {
+	return w.Writer.Write(b)
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+func init() {
+	openapi3.DefineStringFormat("uuid", openapi3.FormatOfStringForUUIDOfRFC4122)
+	openapi3.DefineStringFormat("email", openapi3.FormatOfStringForEmail)
+}
+
+func loadOpenAPISpec() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, fmt.Errorf("could not load OpenAPI spec: %w", err)
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	return doc, nil
+}
+
+func (s Server) GetOpenAPISpec(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, openAPISpec)
+}
+
+type validationErrorResponse struct {
+	Error   string            `json:"error"`
+	Details []validationError `json:"details"`
+}
+
+type validationError struct {
+	In      string `json:"in"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// requestValidationMiddleware validates requests against the OpenAPI spec.
+// Requests to routes which are not in the spec are passed through.
+func requestValidationMiddleware(doc *openapi3.T) (echo.MiddlewareFunc, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("could not create OpenAPI router: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		MultiError: true,
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			route, pathParams, err := router.FindRoute(req)
+			if errors.Is(err, routers.ErrPathNotFound) || errors.Is(err, routers.ErrMethodNotAllowed) {
+				return next(c)
+			}
+			if err != nil {
+				return fmt.Errorf("could not find OpenAPI route: %w", err)
+			}
+
+			err = openapi3filter.ValidateRequest(req.Context(), &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			})
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, validationErrorResponse{
+					Error:   "invalid request",
+					Details: newValidationErrors(err),
+				})
+			}
+
+			return next(c)
+		}
+	}, nil
+}
+
+func newValidationErrors(err error) []validationError {
+	// errors.As is not used, as it would unwrap body errors from the RequestError
+	if multiErr, ok := err.(openapi3.MultiError); ok {
+		var result []validationError
+		for _, e := range multiErr {
+			result = append(result, newValidationErrors(e)...)
+		}
+		return result
+	}
+
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return []validationError{{In: "request", Message: err.Error()}}
+	}
+
+	if requestErr.Parameter != nil {
+		return []validationError{{
+			In:      requestErr.Parameter.In,
+			Field:   requestErr.Parameter.Name,
+			Message: validationErrorMessage(requestErr),
+		}}
+	}
+
+	var bodyErrs openapi3.MultiError
+	if !errors.As(requestErr.Err, &bodyErrs) {
+		bodyErrs = openapi3.MultiError{requestErr.Err}
+	}
+
+	result := make([]validationError, 0, len(bodyErrs))
+	for _, bodyErr := range bodyErrs {
+		validationErr := validationError{In: "body", Message: requestErr.Reason}
+
+		var schemaErr *openapi3.SchemaError
+		if errors.As(bodyErr, &schemaErr) {
+			validationErr.Field = "/" + strings.Join(schemaErr.JSONPointer(), "/")
+			validationErr.Message = schemaErr.Reason
+		} else if bodyErr != nil {
+			validationErr.Message = bodyErr.Error()
+		}
+
+		result = append(result, validationErr)
+	}
+
+	return result
+}
+
+func validationErrorMessage(requestErr *openapi3filter.RequestError) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(requestErr.Err, &schemaErr) {
+		return schemaErr.Reason
+	}
+	if requestErr.Err != nil {
+		return requestErr.Err.Error()
+	}
+
+	return requestErr.Reason
+}
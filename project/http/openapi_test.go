@@ -0,0 +1,98 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var echoPathParam = regexp.MustCompile(`:([a-z_]+)`)
+
+func TestOpenAPISpec_covers_all_routes(t *testing.T) {
+	spec, err := loadOpenAPISpec()
+	require.NoError(t, err)
+
+	server := NewServer(":0", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, time.Minute)
+
+	for _, route := range server.e.Routes() {
+		path := echoPathParam.ReplaceAllString(route.Path, "{$1}")
+
+		pathItem := spec.Paths.Find(path)
+		if !assert.NotNil(t, pathItem, "route %s %s is missing in the OpenAPI spec", route.Method, path) {
+			continue
+		}
+		assert.NotNil(t, pathItem.GetOperation(route.Method), "route %s %s is missing in the OpenAPI spec", route.Method, path)
+	}
+}
+
+func TestRequestValidationMiddleware(t *testing.T) {
+	spec, err := loadOpenAPISpec()
+	require.NoError(t, err)
+
+	validateRequest, err := requestValidationMiddleware(spec)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(validateRequest)
+	e.POST("/book-tickets", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+
+	testCases := []struct {
+		Name               string
+		Body               string
+		ExpectedStatusCode int
+		ExpectedFields     []string
+	}{
+		{
+			Name:               "valid",
+			Body:               `{"show_id": "f2b1b5b0-3c8e-4a3c-8f4b-1d1d1c1b1a19", "number_of_tickets": 2, "customer_email": "test@example.com"}`,
+			ExpectedStatusCode: http.StatusCreated,
+		},
+		{
+			Name:               "invalid_fields",
+			Body:               `{"show_id": "", "number_of_tickets": -1, "customer_email": "not an email"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedFields:     []string{"/show_id", "/number_of_tickets", "/customer_email"},
+		},
+		{
+			Name:               "missing_fields",
+			Body:               `{}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedFields:     []string{"/show_id", "/number_of_tickets", "/customer_email"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/book-tickets", strings.NewReader(tc.Body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.ExpectedStatusCode, rec.Code, rec.Body.String())
+			if tc.ExpectedStatusCode != http.StatusBadRequest {
+				return
+			}
+
+			var resp validationErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+			var fields []string
+			for _, detail := range resp.Details {
+				assert.Equal(t, "body", detail.In)
+				fields = append(fields, detail.Field)
+			}
+			assert.ElementsMatch(t, tc.ExpectedFields, fields)
+		})
+	}
+}
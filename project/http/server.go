@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	echoHTTP "github.com/ThreeDotsLabs/go-event-driven/common/http"
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
@@ -19,51 +20,120 @@ type SpreadsheetsAPI interface {
 	AppendRow(ctx context.Context, spreadsheetName string, row []string) error
 }
 
+type FilesService interface {
+	DownloadFile(ctx context.Context, fileID string) (string, error)
+}
+
 type TicketsRepository interface {
+	Get(ctx context.Context, ticketID string) (entity.Ticket, error)
 	FindAll(ctx context.Context) ([]entity.Ticket, error)
 }
 
+type TicketRefundsRepository interface {
+	Request(ctx context.Context, ticketID string) (entity.TicketRefund, bool, error)
+	Get(ctx context.Context, ticketID string) (entity.TicketRefund, error)
+}
+
 type ShowsRepository interface {
 	Store(ctx context.Context, show entity.Show) error
 	Get(ctx context.Context, showID string) (entity.Show, error)
+	List(ctx context.Context, filter entity.ShowsFilter) ([]entity.Show, string, error)
+	Update(ctx context.Context, showID string, update entity.ShowUpdate) (entity.Show, error)
+	Cancel(ctx context.Context, showID string) error
+}
+
+type ShowCancellationsRepository interface {
+	Get(ctx context.Context, showID string) (entity.ShowCancellation, error)
 }
 
 type BookingsRepository interface {
-	Store(ctx context.Context, booking entity.Booking, showTicketsCount int) error
+	Store(ctx context.Context, booking entity.Booking) error
+	Get(ctx context.Context, bookingID string) (entity.Booking, error)
+	AvailableTickets(ctx context.Context, shows ...entity.Show) (map[string]int, error)
+	Cancel(ctx context.Context, bookingID string) error
+	Hold(ctx context.Context, hold entity.SeatHold) error
+	ConfirmHold(ctx context.Context, holdID string, bookingID string) (entity.Booking, error)
+	JoinWaitlist(ctx context.Context, entry entity.WaitlistEntry) error
+	GetWaitlistEntry(ctx context.Context, entryID string) (entity.WaitlistEntry, error)
+	SetSeatMap(ctx context.Context, showID string, seats []entity.ShowSeat) error
+	ShowSeats(ctx context.Context, showID string) ([]entity.ShowSeat, error)
+	AssignTicketSeats(
+		ctx context.Context,
+		tickets []entity.Ticket,
+		validateFn func(ticket entity.Ticket, seat *entity.Seat) error,
+	) ([]*entity.Seat, error)
 }
 
 type OpsBookingReadModel interface {
-	AllReservations(receiptIssueDateFilter string) ([]entity.OpsBooking, error)
+	AllReservations(ctx context.Context, filter entity.OpsBookingsFilter) ([]entity.OpsBooking, string, error)
 	ReservationReadModel(ctx context.Context, id string) (entity.OpsBooking, error)
+	TicketReadModel(ctx context.Context, ticketID string) (entity.OpsTicket, error)
+	UpdatesPosition(ctx context.Context) (string, error)
+	UpdatedSince(ctx context.Context, position string, bookingID string) ([]entity.OpsBookingUpdate, error)
+}
+
+type OpsBookingUpdatesFeed interface {
+	Subscribe() (<-chan string, func())
 }
 
 type VipBundleRepository interface {
 	Add(ctx context.Context, vipBundle entity.VipBundle) error
+	Get(ctx context.Context, vipBundleID string) (entity.VipBundle, error)
+}
+
+type PromotionsRepository interface {
+	Store(ctx context.Context, promotion entity.Promotion) error
+	Get(ctx context.Context, code string) (entity.Promotion, error)
+}
+
+type IdempotencyKeysRepository interface {
+	Begin(ctx context.Context, idempotencyKey string, requestHash string) (*entity.IdempotentResponse, error)
+	Complete(ctx context.Context, idempotencyKey string, response entity.IdempotentResponse) error
+	Release(ctx context.Context, idempotencyKey string) error
+}
+
+// OutboxEventPublisher publishes all events in one transaction through the outbox.
+type OutboxEventPublisher interface {
+	Publish(ctx context.Context, events ...entity.Event) error
 }
 
 type Server struct {
 	addr                  string
 	e                     *echo.Echo
 	eventbus              *cqrs.EventBus
-	commandBus            *cqrs.CommandBus
+	outboxEventPublisher  OutboxEventPublisher
 	spreadsheetsAPIClient SpreadsheetsAPI
+	filesService          FilesService
 	ticketsRepo           TicketsRepository
+	ticketRefundsRepo     TicketRefundsRepository
 	showsRepo             ShowsRepository
+	showCancellationsRepo ShowCancellationsRepository
 	bookingsRepo          BookingsRepository
 	opsBookingReadModel   OpsBookingReadModel
+	opsBookingUpdatesFeed OpsBookingUpdatesFeed
 	vipBundleRepo         VipBundleRepository
+	promotionsRepo        PromotionsRepository
+	idempotencyKeysRepo   IdempotencyKeysRepository
+	seatHoldTTL           time.Duration
 }
 
 func NewServer(
 	addr string,
 	eventbus *cqrs.EventBus,
-	commandBus *cqrs.CommandBus,
+	outboxEventPublisher OutboxEventPublisher,
 	spreadsheetsAPIClient SpreadsheetsAPI,
+	filesService FilesService,
 	ticketsRepo TicketsRepository,
+	ticketRefundsRepo TicketRefundsRepository,
 	showsRepo ShowsRepository,
+	showCancellationsRepo ShowCancellationsRepository,
 	bookingsRepo BookingsRepository,
 	opsBookingReadModel OpsBookingReadModel,
+	opsBookingUpdatesFeed OpsBookingUpdatesFeed,
 	vipBundleRepo VipBundleRepository,
+	promotionsRepo PromotionsRepository,
+	idempotencyKeysRepo IdempotencyKeysRepository,
+	seatHoldTTL time.Duration,
 ) *Server {
 	e := echoHTTP.NewEcho()
 
@@ -71,30 +141,71 @@ func NewServer(
 		addr:                  addr,
 		e:                     e,
 		eventbus:              eventbus,
-		commandBus:            commandBus,
+		outboxEventPublisher:  outboxEventPublisher,
 		spreadsheetsAPIClient: spreadsheetsAPIClient,
+		filesService:          filesService,
 		ticketsRepo:           ticketsRepo,
+		ticketRefundsRepo:     ticketRefundsRepo,
 		showsRepo:             showsRepo,
+		showCancellationsRepo: showCancellationsRepo,
 		bookingsRepo:          bookingsRepo,
 		opsBookingReadModel:   opsBookingReadModel,
+		opsBookingUpdatesFeed: opsBookingUpdatesFeed,
 		vipBundleRepo:         vipBundleRepo,
+		promotionsRepo:        promotionsRepo,
+		idempotencyKeysRepo:   idempotencyKeysRepo,
+		seatHoldTTL:           seatHoldTTL,
+	}
+
+	idempotent := idempotencyMiddleware(idempotencyKeysRepo)
+
+	spec, err := loadOpenAPISpec()
+	if err != nil {
+		panic(err)
+	}
+	validateRequest, err := requestValidationMiddleware(spec)
+	if err != nil {
+		panic(err)
 	}
 
 	e.Use(otelecho.Middleware("http-server"))
+	e.Use(validateRequest)
 	e.GET("/health", func(c echo.Context) error {
 		return c.String(http.StatusOK, "ok")
 	})
 	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+	e.GET("/openapi.json", server.GetOpenAPISpec)
 
 	e.GET("/ops/bookings", server.GetOpsTickets)
+	e.GET("/ops/bookings/stream", server.GetOpsBookingsStream)
 	e.GET("/ops/bookings/:id", server.GetOpsTicket)
 	e.GET("/tickets", server.GetTickets)
-	e.POST("/tickets-status", server.PostTicketsStatus)
-	e.PUT("/ticket-refund/:ticket_id", server.TicketRefund)
-	e.POST("/book-tickets", server.PostBookTickets)
-	e.POST("/book-vip-bundle", server.PostBookVipBundle)
-
-	e.POST("/shows", server.PostShows)
+	e.GET("/tickets/:id/file", server.GetTicketFile)
+	e.POST("/tickets-status", server.PostTicketsStatus, idempotent)
+	e.PUT("/ticket-refund/:ticket_id", server.TicketRefund, idempotent)
+	e.GET("/ticket-refund/:ticket_id", server.GetTicketRefund)
+	e.POST("/book-tickets", server.PostBookTickets, idempotent)
+	e.POST("/seat-holds", server.PostSeatHolds, idempotent)
+	e.POST("/seat-holds/:id/confirm", server.ConfirmSeatHold, idempotent)
+	e.GET("/bookings/:id", server.GetBooking)
+	e.DELETE("/bookings/:id", server.DeleteBooking, idempotent)
+	e.POST("/book-vip-bundle", server.PostBookVipBundle, idempotent)
+	e.GET("/vip-bundles/:id", server.GetVipBundle)
+
+	e.POST("/shows", server.PostShows, idempotent)
+	e.GET("/shows", server.GetShows)
+	e.GET("/shows/:id", server.GetShow)
+	e.PUT("/shows/:id", server.PutShow, idempotent)
+	e.POST("/shows/:id/cancel", server.CancelShow, idempotent)
+	e.GET("/shows/:id/cancellation", server.GetShowCancellation)
+	e.POST("/shows/:id/waitlist", server.PostShowWaitlist, idempotent)
+	e.PUT("/shows/:id/seats", server.PutShowSeatMap, idempotent)
+	e.GET("/shows/:id/seats", server.GetShowSeats)
+	e.POST("/promotions", server.PostPromotions, idempotent)
+	e.GET("/promotions/:code", server.GetPromotion)
+
+	e.GET("/waitlist/:id", server.GetWaitlistEntry)
+	e.POST("/waitlist/:id/accept", server.AcceptWaitlistOffer, idempotent)
 
 	return server
 }
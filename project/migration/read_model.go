@@ -9,13 +9,18 @@ import (
 	db2 "tickets/db/data_lake"
 	"tickets/db/read_model_ops_bookings"
 	"tickets/entity"
+	"tickets/pubsub/upcasting"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
-func MigrateReadModel(ctx context.Context, dl db2.DataLake, rm read_model_ops_bookings.OpsBookingReadModel) error {
+func MigrateReadModel(
+	ctx context.Context,
+	dl db2.DataLake,
+	upcasters *upcasting.Registry,
+	rm read_model_ops_bookings.OpsBookingReadModel,
+) error {
 	var events []entity.DataLakeEvent
 
 	logger := log.FromContext(ctx)
@@ -52,7 +57,7 @@ func MigrateReadModel(ctx context.Context, dl db2.DataLake, rm read_model_ops_bo
 			"event_id":   event.ID,
 		}).Info("Migrating event")
 
-		err := migrateEvent(ctx, event, rm)
+		err := migrateEvent(ctx, event, upcasters, rm)
 		if err != nil {
 			return fmt.Errorf("could not migrate event %s (%s): %w", event.ID, event.Name, err)
 		}
@@ -63,120 +68,55 @@ func MigrateReadModel(ctx context.Context, dl db2.DataLake, rm read_model_ops_bo
 	return nil
 }
 
-// Lucky, the events stored in the Data Lake are the same as the ones from entities package...
-// but probably you are not.
-//
-// To show you the principle, we implemented v0 events in a way that we would do when the format doesn't match.
-
-type bookingMade_v0 struct {
-	Header entity.EventHeader `json:"header"`
-
-	NumberOfTickets int `json:"number_of_tickets"`
-
-	BookingID uuid.UUID `json:"booking_id"`
-
-	CustomerEmail string    `json:"customer_email"`
-	ShowId        uuid.UUID `json:"show_id"`
-}
-
-type ticketBookingConfirmed_v0 struct {
-	Header entity.EventHeader `json:"header"`
-
-	TicketID      string       `json:"ticket_id"`
-	CustomerEmail string       `json:"customer_email"`
-	Price         entity.Money `json:"price"`
-
-	BookingID string `json:"booking_id"`
-}
-
-type ticketReceiptIssued_v0 struct {
-	Header entity.EventHeader `json:"header"`
-
-	TicketID      string `json:"ticket_id"`
-	ReceiptNumber string `json:"receipt_number"`
-
-	IssuedAt time.Time `json:"issued_at"`
-}
-
-type ticketPrinted_v0 struct {
-	Header entity.EventHeader `json:"header"`
-
-	TicketID string `json:"ticket_id"`
-	FileName string `json:"file_name"`
-}
-
-type ticketRefunded_v0 struct {
-	Header entity.EventHeader `json:"header"`
-
-	TicketID string `json:"ticket_id"`
-}
+// migrateEvent upcasts the event first, so only the latest versions of events are migrated.
+func migrateEvent(
+	ctx context.Context,
+	event entity.DataLakeEvent,
+	upcasters *upcasting.Registry,
+	rm read_model_ops_bookings.OpsBookingReadModel,
+) error {
+	var err error
+	event.Name, event.Payload, err = upcasters.Upcast(event.Name, event.Payload)
+	if err != nil {
+		return err
+	}
 
-func migrateEvent(ctx context.Context, event entity.DataLakeEvent, rm read_model_ops_bookings.OpsBookingReadModel) error {
 	switch event.Name {
-	case "BookingMade_v0":
-		bookingMade, err := unmarshalDataLakeEvent[bookingMade_v0](event)
+	case "BookingMade_v1":
+		bookingMade, err := unmarshalDataLakeEvent[entity.BookingMade_v1](event)
 		if err != nil {
 			return err
 		}
 
-		return rm.OnBookingMade(ctx, &entity.BookingMade_v1{
-			// you should map v0 event to your v1 event here
-			Header:          bookingMade.Header,
-			NumberOfTickets: bookingMade.NumberOfTickets,
-			BookingID:       bookingMade.BookingID.String(),
-			CustomerEmail:   bookingMade.CustomerEmail,
-			ShowID:          bookingMade.ShowId.String(),
-		})
-	case "TicketBookingConfirmed_v0":
-		bookingConfirmedEvent, err := unmarshalDataLakeEvent[ticketBookingConfirmed_v0](event)
+		return rm.OnBookingMade(ctx, bookingMade)
+	case "TicketBookingConfirmed_v1":
+		bookingConfirmedEvent, err := unmarshalDataLakeEvent[entity.TicketBookingConfirmed_v1](event)
 		if err != nil {
 			return err
 		}
 
-		return rm.OnTicketBookingConfirmed(ctx, &entity.TicketBookingConfirmed_v1{
-			// you should map v0 event to your v1 event here
-			Header:        bookingConfirmedEvent.Header,
-			TicketID:      bookingConfirmedEvent.TicketID,
-			CustomerEmail: bookingConfirmedEvent.CustomerEmail,
-			Price:         bookingConfirmedEvent.Price,
-			BookingID:     bookingConfirmedEvent.BookingID,
-		})
-	case "TicketReceiptIssued_v0":
-		receiptIssuedEvent, err := unmarshalDataLakeEvent[ticketReceiptIssued_v0](event)
+		return rm.OnTicketBookingConfirmed(ctx, bookingConfirmedEvent)
+	case "TicketReceiptIssued_v1":
+		receiptIssuedEvent, err := unmarshalDataLakeEvent[entity.TicketReceiptIssued_v1](event)
 		if err != nil {
 			return err
 		}
 
-		return rm.OnTicketReceiptIssued(ctx, &entity.TicketReceiptIssued_v1{
-			// you should map v0 event to your v1 event here
-			Header:        receiptIssuedEvent.Header,
-			TicketID:      receiptIssuedEvent.TicketID,
-			ReceiptNumber: receiptIssuedEvent.ReceiptNumber,
-			IssuedAt:      receiptIssuedEvent.IssuedAt,
-		})
-	case "TicketPrinted_v0":
-		ticketPrintedEvent, err := unmarshalDataLakeEvent[ticketPrinted_v0](event)
+		return rm.OnTicketReceiptIssued(ctx, receiptIssuedEvent)
+	case "TicketPrinted_v1":
+		ticketPrintedEvent, err := unmarshalDataLakeEvent[entity.TicketPrinted_v1](event)
 		if err != nil {
 			return err
 		}
 
-		return rm.OnTicketPrinted(ctx, &entity.TicketPrinted_v1{
-			// you should map v0 event to your v1 event here
-			Header:   ticketPrintedEvent.Header,
-			TicketID: ticketPrintedEvent.TicketID,
-			FileName: ticketPrintedEvent.FileName,
-		})
-	case "TicketRefunded_v0":
-		ticketRefundedEvent, err := unmarshalDataLakeEvent[ticketRefunded_v0](event)
+		return rm.OnTicketPrinted(ctx, ticketPrintedEvent)
+	case "TicketRefunded_v1":
+		ticketRefundedEvent, err := unmarshalDataLakeEvent[entity.TicketRefunded_v1](event)
 		if err != nil {
 			return err
 		}
 
-		return rm.OnTicketRefunded(ctx, &entity.TicketRefunded_v1{
-			// you should map v0 event to your v1 event here
-			Header:   ticketRefundedEvent.Header,
-			TicketID: ticketRefundedEvent.TicketID,
-		})
+		return rm.OnTicketRefunded(ctx, ticketRefundedEvent)
 	default:
 		return fmt.Errorf("unknown event %s", event.Name)
 	}
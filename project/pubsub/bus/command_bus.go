@@ -10,6 +10,9 @@ func NewCommandBus(pub message.Publisher) (*cqrs.CommandBus, error) {
 		GeneratePublishTopic: func(params cqrs.CommandBusGeneratePublishTopicParams) (string, error) {
 			return "commands." + params.CommandName, nil
 		},
+		OnSend: func(params cqrs.CommandBusOnSendParams) error {
+			return setMessageIDs(params.Message)
+		},
 		Marshaler: cqrs.JSONMarshaler{
 			GenerateName: cqrs.StructName,
 		},
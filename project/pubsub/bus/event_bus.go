@@ -26,6 +26,9 @@ func NewEventBus(pub message.Publisher) (*cqrs.EventBus, error) {
 				return "events", nil
 			}
 		},
+		OnPublish: func(params cqrs.OnEventSendParams) error {
+			return setMessageIDs(params.Message)
+		},
 		Marshaler: cqrs.JSONMarshaler{
 			GenerateName: cqrs.StructName,
 		},
@@ -0,0 +1,106 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	correlationIDMetadataKey = "correlation_id"
+	causationIDMetadataKey   = "causation_id"
+)
+
+type causationIDKey struct{}
+
+// messageHeader is the part of the header common to events and commands.
+type messageHeader struct {
+	ID            string `json:"id"`
+	CorrelationID string `json:"correlation_id"`
+	CausationID   string `json:"causation_id"`
+}
+
+// ContextWithMessageIDs returns the context of handling the message. Events and commands published
+// with it have the message's correlation ID, and the message's ID as their causation ID.
+// The IDs are also added to the context's logger.
+func ContextWithMessageIDs(ctx context.Context, msg *message.Message) context.Context {
+	var payload struct {
+		Header messageHeader `json:"header"`
+	}
+	// messages without a header, like the ones forwarded from the outbox, keep only the correlation ID
+	_ = json.Unmarshal(msg.Payload, &payload)
+
+	correlationID := payload.Header.CorrelationID
+	if correlationID == "" {
+		correlationID = msg.Metadata.Get(correlationIDMetadataKey)
+	}
+	causationID := payload.Header.ID
+
+	fields := logrus.Fields{}
+	if correlationID != "" {
+		ctx = log.ContextWithCorrelationID(ctx, correlationID)
+		fields["correlation_id"] = correlationID
+	}
+	if causationID != "" {
+		ctx = context.WithValue(ctx, causationIDKey{}, causationID)
+		fields["causation_id"] = causationID
+	}
+
+	return log.ToContext(ctx, log.FromContext(ctx).WithFields(fields))
+}
+
+// setMessageIDs sets the correlation and causation IDs from the message's context in its header and metadata,
+// unless they were set already.
+func setMessageIDs(msg *message.Message) error {
+	ctx := msg.Context()
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return fmt.Errorf("could not unmarshal message payload: %w", err)
+	}
+
+	headerPayload, ok := payload["header"]
+	if !ok {
+		return fmt.Errorf("message has no header")
+	}
+
+	var header map[string]any
+	if err := json.Unmarshal(headerPayload, &header); err != nil {
+		return fmt.Errorf("could not unmarshal message header: %w", err)
+	}
+
+	correlationID, _ := header[correlationIDMetadataKey].(string)
+	if correlationID == "" {
+		correlationID = log.CorrelationIDFromContext(ctx)
+		header[correlationIDMetadataKey] = correlationID
+	}
+	causationID, _ := header[causationIDMetadataKey].(string)
+	if causationID == "" {
+		var ok bool
+		causationID, ok = ctx.Value(causationIDKey{}).(string)
+		if !ok {
+			// messages published while handling HTTP requests are caused by the request
+			causationID = correlationID
+		}
+		header[causationIDMetadataKey] = causationID
+	}
+
+	var err error
+	payload["header"], err = json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("could not marshal message header: %w", err)
+	}
+	msg.Payload, err = json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal message payload: %w", err)
+	}
+
+	msg.Metadata.Set(correlationIDMetadataKey, correlationID)
+	msg.Metadata.Set(causationIDMetadataKey, causationID)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tickets/entity"
+)
+
+type publisherMock struct {
+	messages []*message.Message
+}
+
+func (p *publisherMock) Publish(_ string, messages ...*message.Message) error {
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+func (p *publisherMock) Close() error {
+	return nil
+}
+
+func TestMessageIDs_propagation(t *testing.T) {
+	publisher := &publisherMock{}
+
+	eventBus, err := NewEventBus(publisher)
+	require.NoError(t, err)
+	commandBus, err := NewCommandBus(publisher)
+	require.NoError(t, err)
+
+	// the event is published while handling an HTTP request
+	httpCtx := log.ContextWithCorrelationID(context.Background(), "request-correlation-id")
+
+	event := entity.TicketRefunded_v1{
+		Header:   entity.NewEventHeader(),
+		TicketID: "ticket-id",
+	}
+	require.NoError(t, eventBus.Publish(httpCtx, event))
+	require.Len(t, publisher.messages, 1)
+
+	var publishedEvent entity.TicketRefunded_v1
+	require.NoError(t, json.Unmarshal(publisher.messages[0].Payload, &publishedEvent))
+	assert.Equal(t, "request-correlation-id", publishedEvent.Header.CorrelationID)
+	assert.Equal(t, "request-correlation-id", publishedEvent.Header.CausationID)
+	assert.Equal(t, "request-correlation-id", publisher.messages[0].Metadata.Get("correlation_id"))
+
+	// the command is sent while handling the event
+	handlerCtx := ContextWithMessageIDs(context.Background(), publisher.messages[0])
+
+	command := entity.CancelFlightTickets{
+		Header:          entity.NewCommandHeader(),
+		FlightTicketIDs: []string{"flight-ticket-id"},
+	}
+	require.NoError(t, commandBus.Send(handlerCtx, command))
+	require.Len(t, publisher.messages, 2)
+
+	var sentCommand entity.CancelFlightTickets
+	require.NoError(t, json.Unmarshal(publisher.messages[1].Payload, &sentCommand))
+	assert.Equal(t, command.Header.ID, sentCommand.Header.ID)
+	assert.Equal(t, "request-correlation-id", sentCommand.Header.CorrelationID)
+	assert.Equal(t, event.Header.ID, sentCommand.Header.CausationID)
+	assert.Equal(t, event.Header.ID, publisher.messages[1].Metadata.Get("causation_id"))
+}
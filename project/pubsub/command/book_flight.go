@@ -29,7 +29,7 @@ func (h Handler) BookFlightHandler() cqrs.CommandHandler {
 			}
 
 			return h.eventBus.Publish(ctx, entity.FlightBooked_v1{
-				Header:      entity.NewEventHeaderWithIdempotencyKey(event.IdempotencyKey),
+				Header:      entity.NewEventHeaderWithIdempotencyKey(event.Header.IdempotencyKey),
 				FlightID:    event.FlightID,
 				ReferenceID: event.ReferenceID,
 				TicketIDs:   ticketIDs,
@@ -22,6 +22,7 @@ func (h Handler) BookShowTicketsHandler() cqrs.CommandHandler {
 				ShowID:          event.ShowId,
 				NumberOfTickets: event.NumberOfTickets,
 				CustomerEmail:   event.CustomerEmail,
+				PromoCode:       event.PromoCode,
 			}
 
 			show, err := h.showsRepo.Get(ctx, event.ShowId)
@@ -29,8 +30,24 @@ func (h Handler) BookShowTicketsHandler() cqrs.CommandHandler {
 				return fmt.Errorf("could not get show: %w", err)
 			}
 
-			err = h.bookingsRepo.Store(ctx, booking, show.NumberOfTickets)
+			if show.CanceledAt != nil {
+				return h.eventBus.Publish(ctx, entity.BookingFailed_v1{
+					Header:        entity.NewEventHeader(),
+					BookingID:     event.BookingID,
+					FailureReason: "show is canceled",
+				})
+			}
+
+			// already stored booking is not stored again, so the command can be handled again after a crash
+			err = h.bookingsRepo.Store(ctx, booking)
 			if err != nil {
+				if errors.Is(err, entity.ErrShowCanceled) {
+					return h.eventBus.Publish(ctx, entity.BookingFailed_v1{
+						Header:        entity.NewEventHeader(),
+						BookingID:     event.BookingID,
+						FailureReason: "show is canceled",
+					})
+				}
 				if errors.Is(err, entity.ErrNoAvailableTickets) {
 					return h.eventBus.Publish(ctx, entity.BookingFailed_v1{
 						Header:        entity.NewEventHeader(),
@@ -38,6 +55,13 @@ func (h Handler) BookShowTicketsHandler() cqrs.CommandHandler {
 						FailureReason: "not enough seats available",
 					})
 				}
+				if errors.Is(err, entity.ErrUnknownPriceCategory) || errors.Is(err, entity.ErrInvalidPromoCode) {
+					return h.eventBus.Publish(ctx, entity.BookingFailed_v1{
+						Header:        entity.NewEventHeader(),
+						BookingID:     event.BookingID,
+						FailureReason: err.Error(),
+					})
+				}
 
 				return fmt.Errorf("could not store booking: %w", err)
 			}
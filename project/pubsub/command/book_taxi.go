@@ -28,7 +28,7 @@ func (h Handler) BookTaxiHandler() cqrs.CommandHandler {
 			}
 
 			return h.eventBus.Publish(ctx, entity.TaxiBooked_v1{
-				Header:        entity.NewEventHeaderWithIdempotencyKey(event.IdempotencyKey),
+				Header:        entity.NewEventHeaderWithIdempotencyKey(event.Header.IdempotencyKey),
 				ReferenceID:   event.ReferenceID,
 				TaxiBookingID: bookingID,
 			})
@@ -12,6 +12,7 @@ import (
 
 func NewProcessorConfig(
 	redisClient *redis.Client,
+	processedCommands ProcessedCommandsRepository,
 	watermillLogger watermill.LoggerAdapter,
 ) cqrs.CommandProcessorConfig {
 	return cqrs.CommandProcessorConfig{
@@ -27,6 +28,7 @@ func NewProcessorConfig(
 		GenerateSubscribeTopic: func(params cqrs.CommandProcessorGenerateSubscribeTopicParams) (string, error) {
 			return fmt.Sprintf("commands.%s", params.CommandName), nil
 		},
+		OnHandle: deduplicateCommands(processedCommands),
 		Marshaler: cqrs.JSONMarshaler{
 			GenerateName: cqrs.StructName,
 		},
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/sirupsen/logrus"
+
+	"tickets/entity"
+)
+
+type ProcessedCommandsRepository interface {
+	Begin(ctx context.Context, handlerName string, commandID string) (bool, error)
+	Complete(ctx context.Context, handlerName string, commandID string) error
+	Release(ctx context.Context, handlerName string, commandID string) error
+}
+
+// deduplicateCommands handles each command only once per handler, even when it's redelivered,
+// and drops expired commands.
+//
+// Commands are recorded as processed separately from the handlers' work, so a command whose handler crashed before
+// it was completed is handled again: handlers must still be idempotent.
+func deduplicateCommands(processedCommands ProcessedCommandsRepository) cqrs.CommandProcessorOnHandleFn {
+	return func(params cqrs.CommandProcessorOnHandleParams) error {
+		ctx := params.Message.Context()
+		handlerName := params.Handler.HandlerName()
+
+		var command struct {
+			Header entity.CommandHeader `json:"header"`
+		}
+		if err := json.Unmarshal(params.Message.Payload, &command); err != nil {
+			return fmt.Errorf("could not unmarshal command header: %w", err)
+		}
+
+		logger := log.FromContext(ctx).WithFields(logrus.Fields{
+			"command_name": params.CommandName,
+			"command_id":   command.Header.ID,
+		})
+
+		if command.Header.IsExpired(time.Now()) {
+			logger.WithField("expires_at", command.Header.ExpiresAt).Warn("Dropping expired command")
+			return nil
+		}
+
+		if command.Header.ID == "" {
+			// commands sent before they had IDs can't be deduplicated
+			return params.Handler.Handle(ctx, params.Command)
+		}
+
+		// ErrCommandInFlight is retried, until the command is completed or abandoned
+		begun, err := processedCommands.Begin(ctx, handlerName, command.Header.ID)
+		if err != nil {
+			return err
+		}
+		if !begun {
+			logger.Info("Skipping already handled command")
+			return nil
+		}
+
+		if err := params.Handler.Handle(ctx, params.Command); err != nil {
+			if releaseErr := processedCommands.Release(ctx, handlerName, command.Header.ID); releaseErr != nil {
+				logger.WithError(releaseErr).Error("could not release command")
+			}
+
+			return err
+		}
+
+		return processedCommands.Complete(ctx, handlerName, command.Header.ID)
+	}
+}
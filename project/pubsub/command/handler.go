@@ -29,7 +29,19 @@ type ShowsRepository interface {
 }
 
 type BookingsRepository interface {
-	Store(ctx context.Context, booking entity.Booking, showTicketsCount int) error
+	Store(ctx context.Context, booking entity.Booking) error
+}
+
+type TicketRefundsRepository interface {
+	MarkFailed(ctx context.Context, ticketID string, failureReason string) error
+}
+
+type TicketsRepository interface {
+	Update(
+		ctx context.Context,
+		ticketID string,
+		updateFn func(ticket entity.Ticket) (entity.Ticket, error),
+	) (entity.Ticket, error)
 }
 
 type Handler struct {
@@ -39,6 +51,8 @@ type Handler struct {
 	transService    TransportationService
 	showsRepo       ShowsRepository
 	bookingsRepo    BookingsRepository
+	refundsRepo     TicketRefundsRepository
+	ticketsRepo     TicketsRepository
 }
 
 func NewHandler(
@@ -48,6 +62,8 @@ func NewHandler(
 	transService TransportationService,
 	showRepo ShowsRepository,
 	bookingRepo BookingsRepository,
+	refundsRepo TicketRefundsRepository,
+	ticketsRepo TicketsRepository,
 ) Handler {
 	if eventBus == nil {
 		panic("missing eventBus")
@@ -67,6 +83,12 @@ func NewHandler(
 	if transService == nil {
 		panic("missing transService")
 	}
+	if refundsRepo == nil {
+		panic("missing refundsRepo")
+	}
+	if ticketsRepo == nil {
+		panic("missing ticketsRepo")
+	}
 
 	return Handler{
 		eventBus:        eventBus,
@@ -75,5 +97,7 @@ func NewHandler(
 		transService:    transService,
 		showsRepo:       showRepo,
 		bookingsRepo:    bookingRepo,
+		refundsRepo:     refundsRepo,
+		ticketsRepo:     ticketsRepo,
 	}
 }
@@ -2,6 +2,9 @@ package command
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
@@ -15,12 +18,42 @@ func (h Handler) RefundTicketHandler() cqrs.CommandHandler {
 		func(ctx context.Context, event *entity.RefundTicket) error {
 			log.FromContext(ctx).Infof("RefundTicketHandler: %s", event.TicketID)
 
+			// the refund is started first, so the ticket is not printed or canceled while it's being refunded,
+			// failed refunds are retried with the same idempotency key and the ticket stays refunding until then
+			_, err := h.ticketsRepo.Update(ctx, event.TicketID, func(ticket entity.Ticket) (entity.Ticket, error) {
+				err := ticket.StartRefund()
+				return ticket, err
+			})
+			if errors.Is(err, entity.ErrIllegalTicketTransition) {
+				log.FromContext(ctx).WithError(err).Warn("Skipping refund of the ticket")
+				if markErr := h.refundsRepo.MarkFailed(ctx, event.TicketID, err.Error()); markErr != nil {
+					return markErr
+				}
+
+				return h.eventBus.Publish(ctx, entity.TicketRefundFailed_v1{
+					Header:   entity.NewEventHeaderWithIdempotencyKey(event.Header.IdempotencyKey),
+					TicketID: event.TicketID,
+					Reason:   err.Error(),
+				})
+			}
+			if err != nil {
+				return fmt.Errorf("could not start refund of ticket %s: %w", event.TicketID, err)
+			}
+
 			if err := h.receiptsService.PutVoidReceiptWithResponse(ctx, *event); err != nil {
-				return err
+				return h.refundFailed(ctx, event.TicketID, fmt.Errorf("could not void receipt: %w", err))
 			}
 
 			if err := h.paymentService.PutRefundsWithResponse(ctx, *event); err != nil {
-				return err
+				return h.refundFailed(ctx, event.TicketID, fmt.Errorf("could not refund payment: %w", err))
+			}
+
+			_, err = h.ticketsRepo.Update(ctx, event.TicketID, func(ticket entity.Ticket) (entity.Ticket, error) {
+				err := ticket.Refund(time.Now())
+				return ticket, err
+			})
+			if err != nil {
+				return fmt.Errorf("could not mark ticket %s as refunded: %w", event.TicketID, err)
 			}
 
 			return h.eventBus.Publish(ctx, entity.TicketRefunded_v1{
@@ -30,3 +63,13 @@ func (h Handler) RefundTicketHandler() cqrs.CommandHandler {
 		},
 	)
 }
+
+// refundFailed records the failure, so it's visible in the refund status, and returns the error,
+// so the refund is retried.
+func (h Handler) refundFailed(ctx context.Context, ticketID string, err error) error {
+	if markErr := h.refundsRepo.MarkFailed(ctx, ticketID, err.Error()); markErr != nil {
+		log.FromContext(ctx).WithError(markErr).Error("could not mark refund as failed")
+	}
+
+	return err
+}
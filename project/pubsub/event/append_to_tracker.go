@@ -17,7 +17,7 @@ func (h Handler) AppendToTrackerHandler() cqrs.EventHandler {
 			return h.spreadsheetsService.AppendRow(
 				ctx,
 				"tickets-to-print",
-				[]string{event.TicketID, event.CustomerEmail, event.Price.Amount, event.Price.Currency},
+				[]string{event.TicketID, event.CustomerEmail, event.Price.AmountString(), event.Price.Currency},
 			)
 		},
 	)
@@ -0,0 +1,58 @@
+package event
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+
+	"tickets/entity"
+)
+
+func (h Handler) RefundCanceledBookingTicketsHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"RefundCanceledBookingTicketsHandler",
+		func(ctx context.Context, event *entity.BookingCanceled_v1) error {
+			log.FromContext(ctx).Info("Refunding tickets of canceled booking")
+
+			tickets, err := h.ticketsRepository.FindByBookingID(ctx, event.BookingID)
+			if err != nil {
+				return fmt.Errorf("could not find tickets of booking %s: %w", event.BookingID, err)
+			}
+
+			for _, ticket := range tickets {
+				err := h.commandBus.Send(ctx, entity.RefundTicket{
+					// the key is stable per ticket, so the ticket is not refunded twice
+					Header:   entity.NewCommandHeaderWithIdempotencyKey(entity.TicketRefundIdempotencyKey(ticket.TicketID)),
+					TicketID: ticket.TicketID,
+				})
+				if err != nil {
+					return fmt.Errorf("could not send refund for ticket %s: %w", ticket.TicketID, err)
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+// CancelDeadNationBookingHandler records the canceled booking to be canceled at Dead Nation manually,
+// Dead Nation API doesn't support booking cancellation.
+func (h Handler) CancelDeadNationBookingHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"CancelDeadNationBookingHandler",
+		func(ctx context.Context, event *entity.BookingCanceled_v1) error {
+			log.FromContext(ctx).
+				WithField("booking_id", event.BookingID).
+				Warn("Booking needs to be canceled in Dead Nation manually")
+
+			err := h.deadNationCancellationsRepo.RequestManualCancellation(ctx, event.BookingID)
+			if err != nil {
+				return fmt.Errorf("failed to request manual cancellation of ticket booking in Dead Nation: %w", err)
+			}
+
+			return nil
+		},
+	)
+}
@@ -10,13 +10,21 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"tickets/entity"
+	"tickets/pubsub/upcasting"
 )
 
 var marshaler = cqrs.JSONMarshaler{
 	GenerateName: cqrs.StructName,
 }
 
-func NewProcessorConfig(redisClient *redis.Client, watermillLogger watermill.LoggerAdapter) cqrs.EventProcessorConfig {
+// NewProcessorConfig returns the config of the processor whose handlers receive only the latest versions of events,
+// events published in older versions are upcasted by the upcasters.
+// Handlers of older versions of events, see upcasting.Registry.OlderVersionsHandlers, subscribe to their versions' topics.
+func NewProcessorConfig(
+	redisClient *redis.Client,
+	upcasters *upcasting.Registry,
+	watermillLogger watermill.LoggerAdapter,
+) cqrs.EventProcessorConfig {
 	return cqrs.EventProcessorConfig{
 		GenerateSubscribeTopic: func(params cqrs.EventProcessorGenerateSubscribeTopicParams) (string, error) {
 			handlerEvent := params.EventHandler.NewEvent()
@@ -32,7 +40,7 @@ func NewProcessorConfig(redisClient *redis.Client, watermillLogger watermill.Log
 				prefix = "events."
 			}
 
-			return fmt.Sprintf(prefix + params.EventName), nil
+			return fmt.Sprintf(prefix + upcasting.SubscribeEventName(params)), nil
 		},
 		SubscriberConstructor: func(params cqrs.EventProcessorSubscriberConstructorParams) (message.Subscriber, error) {
 			return redisstream.NewSubscriber(redisstream.SubscriberConfig{
@@ -40,7 +48,7 @@ func NewProcessorConfig(redisClient *redis.Client, watermillLogger watermill.Log
 				ConsumerGroup: "svc-tickets.events." + params.HandlerName,
 			}, watermillLogger)
 		},
-		Marshaler: marshaler,
+		Marshaler: upcasting.NewMarshaler(marshaler, upcasters),
 		Logger:    watermillLogger,
 	}
 }
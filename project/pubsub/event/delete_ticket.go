@@ -2,6 +2,7 @@ package event
 
 import (
 	"context"
+	"time"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
@@ -13,8 +14,13 @@ func (h Handler) DeleteTicketHandler() cqrs.EventHandler {
 	return cqrs.NewEventHandler(
 		"DeleteTicketHandler",
 		func(ctx context.Context, event *entity.TicketBookingCanceled_v1) error {
-			log.FromContext(ctx).Info("Deleting ticket from DB")
-			return h.ticketsRepository.Delete(ctx, event.TicketID)
+			log.FromContext(ctx).Info("Canceling ticket in DB")
+
+			// refunded tickets are not canceled
+			_, err := h.transitionTicket(ctx, event.TicketID, func(ticket *entity.Ticket) error {
+				return ticket.Cancel(time.Now())
+			})
+			return err
 		},
 	)
 }
@@ -2,6 +2,7 @@ package event
 
 import (
 	"context"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 
@@ -19,7 +20,13 @@ type ReceiptsService interface {
 
 type TicketsRepository interface {
 	Store(ctx context.Context, ticket entity.Ticket) error
-	Delete(ctx context.Context, ticketID string) error
+	Update(
+		ctx context.Context,
+		ticketID string,
+		updateFn func(ticket entity.Ticket) (entity.Ticket, error),
+	) (entity.Ticket, error)
+	FindByBookingID(ctx context.Context, bookingID string) ([]entity.Ticket, error)
+	Get(ctx context.Context, ticketID string) (entity.Ticket, error)
 }
 
 type ShowsRepository interface {
@@ -27,6 +34,11 @@ type ShowsRepository interface {
 	Get(ctx context.Context, showID string) (entity.Show, error)
 }
 
+type BookingsRepository interface {
+	Get(ctx context.Context, bookingID string) (entity.Booking, error)
+	OfferWaitlist(ctx context.Context, showID string, offerTTL time.Duration) (int, error)
+}
+
 type FileService interface {
 	UploadFile(ctx context.Context, fileID string, fileContent string) error
 	DownloadFile(ctx context.Context, fileID string) (string, error)
@@ -36,34 +48,49 @@ type DeadNationService interface {
 	PostTicketBooking(ctx context.Context, BookingID, CustomerAddress, EventID string, NumberOfTickets int) error
 }
 
+type DeadNationCancellationsRepository interface {
+	RequestManualCancellation(ctx context.Context, bookingID string) error
+}
+
 type PaymentService interface {
 	PutRefundsWithResponse(ctx context.Context, command entity.RefundTicket) error
 }
 
 type Handler struct {
-	eventbus            *cqrs.EventBus
-	spreadsheetsService SpreadsheetsAPI
-	receiptsService     ReceiptsService
-	filesService        FileService
-	deadNationService   DeadNationService
-	paymentService      PaymentService
-	ticketsRepository   TicketsRepository
-	showsRepo           ShowsRepository
+	eventbus                    *cqrs.EventBus
+	commandBus                  *cqrs.CommandBus
+	spreadsheetsService         SpreadsheetsAPI
+	receiptsService             ReceiptsService
+	filesService                FileService
+	deadNationService           DeadNationService
+	deadNationCancellationsRepo DeadNationCancellationsRepository
+	paymentService              PaymentService
+	ticketsRepository           TicketsRepository
+	showsRepo                   ShowsRepository
+	bookingsRepo                BookingsRepository
+	waitlistOfferTTL            time.Duration
 }
 
 func NewHandler(
 	eventbus *cqrs.EventBus,
+	commandBus *cqrs.CommandBus,
 	spreadsheetsService SpreadsheetsAPI,
 	receiptsService ReceiptsService,
 	filesService FileService,
 	deadNationService DeadNationService,
+	deadNationCancellationsRepo DeadNationCancellationsRepository,
 	paymentService PaymentService,
 	ticketsRepository TicketsRepository,
 	showsRepo ShowsRepository,
+	bookingsRepo BookingsRepository,
+	waitlistOfferTTL time.Duration,
 ) Handler {
 	if eventbus == nil {
 		panic("missing eventbus")
 	}
+	if commandBus == nil {
+		panic("missing commandBus")
+	}
 	if spreadsheetsService == nil {
 		panic("missing spreadsheetsService")
 	}
@@ -76,6 +103,9 @@ func NewHandler(
 	if deadNationService == nil {
 		panic("missing deadNationService")
 	}
+	if deadNationCancellationsRepo == nil {
+		panic("missing deadNationCancellationsRepo")
+	}
 	if ticketsRepository == nil {
 		panic("missing ticketsRepository")
 	}
@@ -85,15 +115,22 @@ func NewHandler(
 	if paymentService == nil {
 		panic("missing paymentService")
 	}
+	if bookingsRepo == nil {
+		panic("missing bookingsRepo")
+	}
 
 	return Handler{
-		eventbus:            eventbus,
-		spreadsheetsService: spreadsheetsService,
-		receiptsService:     receiptsService,
-		filesService:        filesService,
-		deadNationService:   deadNationService,
-		paymentService:      paymentService,
-		ticketsRepository:   ticketsRepository,
-		showsRepo:           showsRepo,
+		eventbus:                    eventbus,
+		commandBus:                  commandBus,
+		spreadsheetsService:         spreadsheetsService,
+		receiptsService:             receiptsService,
+		filesService:                filesService,
+		deadNationService:           deadNationService,
+		deadNationCancellationsRepo: deadNationCancellationsRepo,
+		paymentService:              paymentService,
+		ticketsRepository:           ticketsRepository,
+		showsRepo:                   showsRepo,
+		bookingsRepo:                bookingsRepo,
+		waitlistOfferTTL:            waitlistOfferTTL,
 	}
 }
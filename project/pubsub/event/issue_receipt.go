@@ -3,6 +3,7 @@ package event
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
@@ -15,6 +16,16 @@ func (h Handler) IssueReceiptHandler() cqrs.EventHandler {
 		"IssueReceiptHandler",
 		func(ctx context.Context, event *entity.TicketBookingConfirmed_v1) error {
 			log.FromContext(ctx).Info("Issuing receipt")
+
+			// receipts of refunded and canceled tickets are not issued
+			ok, err := h.checkTicketTransition(ctx, event.TicketID, func(ticket *entity.Ticket) error {
+				return ticket.IssueReceipt("", time.Now())
+			})
+			if err != nil || !ok {
+				return err
+			}
+
+			// the price is already discounted when the ticket is confirmed
 			request := entity.IssueReceiptRequest{
 				TicketID:       event.TicketID,
 				Price:          event.Price,
@@ -26,6 +37,13 @@ func (h Handler) IssueReceiptHandler() cqrs.EventHandler {
 				return fmt.Errorf("failed to issue receipt: %w", err)
 			}
 
+			ok, err = h.transitionTicket(ctx, event.TicketID, func(ticket *entity.Ticket) error {
+				return ticket.IssueReceipt(resp.ReceiptNumber, resp.IssuedAt)
+			})
+			if err != nil || !ok {
+				return err
+			}
+
 			return h.eventbus.Publish(ctx, entity.TicketReceiptIssued_v1{
 				Header:        entity.NewEventHeaderWithIdempotencyKey(event.Header.IdempotencyKey),
 				TicketID:      event.TicketID,
@@ -0,0 +1,90 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+
+	"tickets/entity"
+)
+
+func (h Handler) OfferWaitlistOnWaitlistJoinedHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"OfferWaitlistOnWaitlistJoinedHandler",
+		func(ctx context.Context, event *entity.WaitlistJoined_v1) error {
+			// seats may have been freed before the customer joined the waitlist
+			return h.offerWaitlist(ctx, event.ShowID)
+		},
+	)
+}
+
+func (h Handler) OfferWaitlistOnBookingCanceledHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"OfferWaitlistOnBookingCanceledHandler",
+		func(ctx context.Context, event *entity.BookingCanceled_v1) error {
+			return h.offerWaitlist(ctx, event.ShowID)
+		},
+	)
+}
+
+func (h Handler) OfferWaitlistOnSeatHoldExpiredHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"OfferWaitlistOnSeatHoldExpiredHandler",
+		func(ctx context.Context, event *entity.SeatHoldExpired_v1) error {
+			return h.offerWaitlist(ctx, event.ShowID)
+		},
+	)
+}
+
+func (h Handler) OfferWaitlistOnTicketRefundedHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"OfferWaitlistOnTicketRefundedHandler",
+		func(ctx context.Context, event *entity.TicketRefunded_v1) error {
+			ticket, err := h.ticketsRepository.Get(ctx, event.TicketID)
+			if err != nil {
+				return fmt.Errorf("could not get ticket %s: %w", event.TicketID, err)
+			}
+
+			if ticket.BookingID == "" {
+				return nil
+			}
+
+			booking, err := h.bookingsRepo.Get(ctx, ticket.BookingID)
+			if errors.Is(err, entity.ErrNotFound) {
+				// tickets booked outside of the service don't have bookings, so there is no show to offer
+				log.FromContext(ctx).WithField("booking_id", ticket.BookingID).Debug("Skipping waitlist offer of unknown booking")
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("could not get booking %s: %w", ticket.BookingID, err)
+			}
+
+			return h.offerWaitlist(ctx, booking.ShowID)
+		},
+	)
+}
+
+func (h Handler) offerWaitlist(ctx context.Context, showID string) error {
+	show, err := h.showsRepo.Get(ctx, showID)
+	if err != nil {
+		return fmt.Errorf("could not get show %s: %w", showID, err)
+	}
+
+	if show.CanceledAt != nil {
+		return nil
+	}
+
+	offers, err := h.bookingsRepo.OfferWaitlist(ctx, showID, h.waitlistOfferTTL)
+	if err != nil {
+		return fmt.Errorf("could not offer seats to waitlist of show %s: %w", showID, err)
+	}
+
+	if offers > 0 {
+		log.FromContext(ctx).Infof("Offered seats of show %s to %d waiting customers", showID, offers)
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package event
 import (
 	"context"
 	"fmt"
+	"html"
+	"time"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
@@ -16,6 +18,22 @@ func (h Handler) PrintTicketHandler() cqrs.EventHandler {
 		func(ctx context.Context, event *entity.TicketBookingConfirmed_v1) error {
 			log.FromContext(ctx).Info("Printing ticket")
 
+			printedAt := time.Now()
+			printTicket := func(ticket *entity.Ticket) error {
+				return ticket.Print(printedAt)
+			}
+
+			// refunded and canceled tickets are not printed
+			ok, err := h.checkTicketTransition(ctx, event.TicketID, printTicket)
+			if err != nil || !ok {
+				return err
+			}
+
+			var seatHTML string
+			if event.Seat != nil {
+				seatHTML = `<p>Seat: ` + html.EscapeString(event.Seat.String()) + `</p>`
+			}
+
 			ticketHTML := `
 			<html>
 				<head>
@@ -23,17 +41,23 @@ func (h Handler) PrintTicketHandler() cqrs.EventHandler {
 				</head>
 				<body>
 					<h1>Ticket ` + event.TicketID + `</h1>
-					<p>Price: ` + event.Price.Amount + ` ` + event.Price.Currency + `</p>	
+					<p>Price: ` + event.Price.String() + `</p>	
+					` + seatHTML + `
 				</body>
 			</html>
 			`
 
 			fileID := fmt.Sprintf("%s-ticket.html", event.TicketID)
-			err := h.filesService.UploadFile(ctx, fileID, ticketHTML)
+			err = h.filesService.UploadFile(ctx, fileID, ticketHTML)
 			if err != nil {
 				return err
 			}
 
+			ok, err = h.transitionTicket(ctx, event.TicketID, printTicket)
+			if err != nil || !ok {
+				return err
+			}
+
 			ticketPrinter := entity.TicketPrinted_v1{
 				Header:   entity.NewEventHeader(),
 				TicketID: event.TicketID,
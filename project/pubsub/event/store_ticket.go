@@ -14,12 +14,10 @@ func (h Handler) StoreTicketHandler() cqrs.EventHandler {
 		"StoreTicketHandler",
 		func(ctx context.Context, event *entity.TicketBookingConfirmed_v1) error {
 			log.FromContext(ctx).Info("Storing ticket in DB")
-			return h.ticketsRepository.Store(ctx, entity.Ticket{
-				TicketID:      event.TicketID,
-				PriceAmount:   event.Price.Amount,
-				PriceCurrency: event.Price.Currency,
-				CustomerEmail: event.CustomerEmail,
-			})
+			return h.ticketsRepository.Store(
+				ctx,
+				entity.NewTicket(event.TicketID, event.BookingID, event.Price, event.CustomerEmail),
+			)
 		},
 	)
 }
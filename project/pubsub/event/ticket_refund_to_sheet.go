@@ -17,7 +17,7 @@ func (h Handler) CancelTicketHandler() cqrs.EventHandler {
 			return h.spreadsheetsService.AppendRow(
 				ctx,
 				"tickets-to-refund",
-				[]string{event.TicketID, event.CustomerEmail, event.Price.Amount, event.Price.Currency},
+				[]string{event.TicketID, event.CustomerEmail, event.Price.AmountString(), event.Price.Currency},
 			)
 		},
 	)
@@ -0,0 +1,53 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+
+	"tickets/entity"
+)
+
+// transitionTicket changes the stored ticket's state with transition.
+// Illegal transitions are logged and skipped, so the returned bool is false and the handler shouldn't continue.
+func (h Handler) transitionTicket(ctx context.Context, ticketID string, transition func(ticket *entity.Ticket) error) (bool, error) {
+	_, err := h.ticketsRepository.Update(ctx, ticketID, func(ticket entity.Ticket) (entity.Ticket, error) {
+		err := transition(&ticket)
+		return ticket, err
+	})
+	if errors.Is(err, entity.ErrIllegalTicketTransition) {
+		log.FromContext(ctx).WithError(err).Warn("Skipping illegal ticket transition")
+		return false, nil
+	}
+	if errors.Is(err, entity.ErrNotFound) {
+		// tickets are stored concurrently with other handlers of the confirmation, so it's retried
+		return false, fmt.Errorf("ticket %s is not stored yet: %w", ticketID, err)
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not update ticket %s: %w", ticketID, err)
+	}
+
+	return true, nil
+}
+
+// checkTicketTransition returns false when the transition of the stored ticket would be illegal,
+// so side effects of the transition can be skipped before it's stored.
+func (h Handler) checkTicketTransition(ctx context.Context, ticketID string, transition func(ticket *entity.Ticket) error) (bool, error) {
+	ticket, err := h.ticketsRepository.Get(ctx, ticketID)
+	if errors.Is(err, entity.ErrNotFound) {
+		return false, fmt.Errorf("ticket %s is not stored yet: %w", ticketID, err)
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not get ticket %s: %w", ticketID, err)
+	}
+
+	err = transition(&ticket)
+	if errors.Is(err, entity.ErrIllegalTicketTransition) {
+		log.FromContext(ctx).WithError(err).Warn("Skipping illegal ticket transition")
+		return false, nil
+	}
+
+	return err == nil, err
+}
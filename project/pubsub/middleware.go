@@ -16,6 +16,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"tickets/metrics"
+	"tickets/pubsub/bus"
 )
 
 func useMiddlewares(router *message.Router, watermillLogger watermill.LoggerAdapter) {
@@ -51,6 +52,15 @@ func useMiddlewares(router *message.Router, watermillLogger watermill.LoggerAdap
 		}
 	})
 
+	router.AddMiddleware(func(next message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) ([]*message.Message, error) {
+			// events and commands published by handlers are correlated with the handled message
+			msg.SetContext(bus.ContextWithMessageIDs(msg.Context(), msg))
+
+			return next(msg)
+		}
+	})
+
 	router.AddMiddleware(func(next message.HandlerFunc) message.HandlerFunc {
 		return func(msg *message.Message) ([]*message.Message, error) {
 			traceID := trace.SpanFromContext(msg.Context()).SpanContext().TraceID().String()
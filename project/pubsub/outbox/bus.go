@@ -0,0 +1,43 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/jmoiron/sqlx"
+
+	"tickets/pubsub/bus"
+)
+
+// NewEventBusForTx returns an event bus which publishes events through the outbox,
+// so they are published only when the transaction is committed.
+func NewEventBusForTx(ctx context.Context, tx *sqlx.Tx) (*cqrs.EventBus, error) {
+	outboxPublisher, err := NewPublisherForDb(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("could not create outbox publisher: %w", err)
+	}
+
+	eventBus, err := bus.NewEventBus(outboxPublisher)
+	if err != nil {
+		return nil, fmt.Errorf("could not create event bus: %w", err)
+	}
+
+	return eventBus, nil
+}
+
+// NewCommandBusForTx returns a command bus which sends commands through the outbox,
+// so they are sent only when the transaction is committed.
+func NewCommandBusForTx(ctx context.Context, tx *sqlx.Tx) (*cqrs.CommandBus, error) {
+	outboxPublisher, err := NewPublisherForDb(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("could not create outbox publisher: %w", err)
+	}
+
+	commandBus, err := bus.NewCommandBus(outboxPublisher)
+	if err != nil {
+		return nil, fmt.Errorf("could not create command bus: %w", err)
+	}
+
+	return commandBus, nil
+}
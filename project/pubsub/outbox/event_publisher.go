@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"tickets/db"
+	"tickets/entity"
+)
+
+// EventPublisher publishes events through the outbox.
+// All events passed to a single Publish call are stored in one transaction, so either all of them
+// are published, or none of them. They are forwarded to the broker when it's available.
+type EventPublisher struct {
+	db *sqlx.DB
+}
+
+func NewEventPublisher(db *sqlx.DB) EventPublisher {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return EventPublisher{db: db}
+}
+
+func (p EventPublisher) Publish(ctx context.Context, events ...entity.Event) error {
+	return db.UpdateInTx(
+		ctx,
+		p.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			eventBus, err := NewEventBusForTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			for _, event := range events {
+				if err := eventBus.Publish(ctx, event); err != nil {
+					return fmt.Errorf("could not publish %T: %w", event, err)
+				}
+			}
+
+			return nil
+		},
+	)
+}
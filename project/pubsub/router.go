@@ -8,13 +8,20 @@ import (
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
 
+	"tickets/db/bookings"
 	"tickets/db/read_model_ops_bookings"
+	"tickets/db/ticket_refunds"
 	"tickets/entity"
 	"tickets/pubsub/command"
 	"tickets/pubsub/event"
 	"tickets/pubsub/outbox"
+	"tickets/pubsub/upcasting"
 )
 
+var publishedEventsMarshaler = cqrs.JSONMarshaler{
+	GenerateName: cqrs.StructName,
+}
+
 type DataLake interface {
 	StoreEvent(ctx context.Context, dataLakeEvent entity.DataLakeEvent) error
 }
@@ -24,12 +31,17 @@ func NewWatermillRouter(
 	redisPublisher message.Publisher,
 	redisSubscriber message.Subscriber,
 	eventProcessorConfig cqrs.EventProcessorConfig,
+	upcasters *upcasting.Registry,
 	eventHandler event.Handler,
 	commandProcessorConfig cqrs.CommandProcessorConfig,
 	commandsHandler command.Handler,
 	opsReadModel read_model_ops_bookings.OpsBookingReadModel,
+	opsUpdatesFeed *read_model_ops_bookings.UpdatesFeed,
+	ticketRefundsRepo *ticket_refunds.PostgresRepository,
+	bookingsRepo *bookings.PostgresRepository,
 	dataLake DataLake,
 	vipBundleProcessManager *entity.VipBundleProcessManager,
+	showCancellationProcessManager *entity.ShowCancellationProcessManager,
 	watermillLogger watermill.LoggerAdapter,
 ) (*message.Router, error) {
 	router, err := message.NewRouter(message.RouterConfig{}, watermillLogger)
@@ -46,7 +58,7 @@ func NewWatermillRouter(
 		return nil, fmt.Errorf("could not create event processor: %w", err)
 	}
 
-	err = eventProcessor.AddHandlers(
+	eventHandlers := []cqrs.EventHandler{
 		eventHandler.StoreTicketHandler(),
 		eventHandler.AppendToTrackerHandler(),
 		eventHandler.IssueReceiptHandler(),
@@ -54,6 +66,12 @@ func NewWatermillRouter(
 		eventHandler.DeleteTicketHandler(),
 		eventHandler.PrintTicketHandler(),
 		eventHandler.PostTicketBookingHandler(),
+		eventHandler.RefundCanceledBookingTicketsHandler(),
+		eventHandler.CancelDeadNationBookingHandler(),
+		eventHandler.OfferWaitlistOnWaitlistJoinedHandler(),
+		eventHandler.OfferWaitlistOnBookingCanceledHandler(),
+		eventHandler.OfferWaitlistOnSeatHoldExpiredHandler(),
+		eventHandler.OfferWaitlistOnTicketRefundedHandler(),
 		cqrs.NewEventHandler(
 			"ops_read_model.OnBookingMade",
 			opsReadModel.OnBookingMade,
@@ -74,6 +92,30 @@ func NewWatermillRouter(
 			"ops_read_model.OnTicketRefunded",
 			opsReadModel.OnTicketRefunded,
 		),
+		cqrs.NewEventHandler(
+			"ticket_refunds.OnTicketRefunded",
+			ticketRefundsRepo.OnTicketRefunded,
+		),
+		cqrs.NewEventHandler(
+			"bookings.OnTicketRefunded",
+			bookingsRepo.OnTicketRefunded,
+		),
+		cqrs.NewEventHandler(
+			"show_cancellation_process_manager.OnShowCanceled",
+			showCancellationProcessManager.OnShowCanceled,
+		),
+		cqrs.NewEventHandler(
+			"show_cancellation_process_manager.OnTicketRefunded",
+			showCancellationProcessManager.OnTicketRefunded,
+		),
+		cqrs.NewEventHandler(
+			"show_cancellation_process_manager.OnTicketRefundFailed",
+			showCancellationProcessManager.OnTicketRefundFailed,
+		),
+		cqrs.NewEventHandler(
+			"show_cancellation_process_manager.OnTicketBookingConfirmed",
+			showCancellationProcessManager.OnTicketBookingConfirmed,
+		),
 		cqrs.NewEventHandler(
 			"vip_bundle_process_manager.OnVipBundleInitialized",
 			vipBundleProcessManager.OnVipBundleInitialized,
@@ -106,7 +148,15 @@ func NewWatermillRouter(
 			"vip_bundle_process_manager.OnTaxiBookingFailed",
 			vipBundleProcessManager.OnTaxiBookingFailed,
 		),
-	)
+		cqrs.NewEventHandler(
+			"vip_bundle_process_manager.OnVipBundleStepTimedOut",
+			vipBundleProcessManager.OnVipBundleStepTimedOut,
+		),
+	}
+	// events published in older versions before they changed are still in their versions' topics
+	eventHandlers = append(eventHandlers, upcasters.OlderVersionsHandlers(publishedEventsMarshaler, eventHandlers...)...)
+
+	err = eventProcessor.AddHandlers(eventHandlers...)
 	if err != nil {
 		return nil, fmt.Errorf("could not add handlers to event processor: %w", err)
 	}
@@ -143,12 +193,30 @@ func NewWatermillRouter(
 		},
 	)
 
+	// redisSubscriber has no consumer group, so every service instance receives all updates
+	// and can notify its own HTTP stream subscribers
+	router.AddNoPublisherHandler(
+		"ops_read_model_updates_feed",
+		"internal-events.svc-tickets."+eventProcessorConfig.Marshaler.Name(&entity.InternalOpsReadModelUpdated{}),
+		redisSubscriber,
+		func(msg *message.Message) error {
+			var event entity.InternalOpsReadModelUpdated
+			if err := eventProcessorConfig.Marshaler.Unmarshal(msg, &event); err != nil {
+				return fmt.Errorf("could not unmarshal event: %w", err)
+			}
+
+			return opsUpdatesFeed.OnReadModelUpdated(msg.Context(), &event)
+		},
+	)
+
 	router.AddNoPublisherHandler(
 		"store_to_data_lake",
 		"events",
 		redisSubscriber,
 		func(msg *message.Message) error {
-			eventName := eventProcessorConfig.Marshaler.NameFromMessage(msg)
+			// events are stored as they were published, the processor's marshaler would return the name
+			// of their latest version
+			eventName := publishedEventsMarshaler.NameFromMessage(msg)
 			if eventName == "" {
 				return fmt.Errorf("could not get event name from message")
 			}
@@ -170,6 +238,9 @@ func NewWatermillRouter(
 					PublishedAt: event.Header.PublishedAt,
 					Name:        eventName,
 					Payload:     msg.Payload,
+
+					CorrelationID: event.Header.CorrelationID,
+					CausationID:   event.Header.CausationID,
 				},
 			)
 		},
@@ -0,0 +1,142 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jmoiron/sqlx"
+
+	"tickets/db"
+	"tickets/entity"
+	"tickets/pubsub/bus"
+	"tickets/pubsub/outbox"
+)
+
+// publishBatchSize limits the number of scheduled messages published in one transaction.
+const publishBatchSize = 100
+
+// Scheduler publishes events at a later time. Scheduled events are stored in Postgres,
+// so they are published even when the service is restarted before they are due.
+type Scheduler struct {
+	db *sqlx.DB
+}
+
+func NewScheduler(db *sqlx.DB) *Scheduler {
+	if db == nil {
+		panic("db must be set")
+	}
+
+	return &Scheduler{db: db}
+}
+
+// Schedule stores the event to be published at publishAt.
+// The event is marshaled now, so it keeps the correlation and causation IDs of the scheduling context.
+func (s *Scheduler) Schedule(ctx context.Context, event entity.Event, publishAt time.Time) error {
+	eventBus, err := bus.NewEventBus(schedulingPublisher{db: s.db, publishAt: publishAt})
+	if err != nil {
+		return fmt.Errorf("could not create event bus: %w", err)
+	}
+
+	if err := eventBus.Publish(ctx, event); err != nil {
+		return fmt.Errorf("could not schedule %T: %w", event, err)
+	}
+
+	return nil
+}
+
+type scheduledMessage struct {
+	UUID     string `db:"message_uuid"`
+	Topic    string `db:"topic"`
+	Metadata []byte `db:"metadata"`
+	Payload  []byte `db:"payload"`
+}
+
+// PublishDue publishes the events which are due through the outbox and returns their count.
+// Published events are deleted in the same transaction, so the scheduled messages don't pile up.
+func (s *Scheduler) PublishDue(ctx context.Context) (int, error) {
+	var due []scheduledMessage
+
+	err := db.UpdateInTx(
+		ctx,
+		s.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			err := tx.SelectContext(ctx, &due, `
+				DELETE FROM scheduled_messages
+				WHERE message_uuid IN (
+					SELECT message_uuid
+					FROM scheduled_messages
+					WHERE published_at IS NULL AND publish_at <= NOW()
+					ORDER BY publish_at
+					LIMIT $1
+					FOR UPDATE SKIP LOCKED
+				)
+				RETURNING message_uuid, topic, metadata, payload
+			`, publishBatchSize)
+			if err != nil {
+				return fmt.Errorf("could not get due scheduled messages: %w", err)
+			}
+
+			if len(due) == 0 {
+				return nil
+			}
+
+			publisher, err := outbox.NewPublisherForDb(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			for _, scheduled := range due {
+				msg := message.NewMessage(scheduled.UUID, scheduled.Payload)
+				if err := json.Unmarshal(scheduled.Metadata, &msg.Metadata); err != nil {
+					return fmt.Errorf("could not unmarshal metadata of scheduled message %s: %w", scheduled.UUID, err)
+				}
+				msg.SetContext(ctx)
+
+				if err := publisher.Publish(scheduled.Topic, msg); err != nil {
+					return fmt.Errorf("could not publish scheduled message %s: %w", scheduled.UUID, err)
+				}
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(due), nil
+}
+
+// schedulingPublisher stores messages in Postgres instead of publishing them.
+type schedulingPublisher struct {
+	db        *sqlx.DB
+	publishAt time.Time
+}
+
+func (p schedulingPublisher) Publish(topic string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return fmt.Errorf("could not marshal metadata: %w", err)
+		}
+
+		_, err = p.db.ExecContext(msg.Context(), `
+			INSERT INTO scheduled_messages (message_uuid, topic, metadata, payload, publish_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, msg.UUID, topic, metadata, msg.Payload, p.publishAt)
+		if err != nil {
+			return fmt.Errorf("could not store scheduled message: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (p schedulingPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbutils "tickets/db"
+	"tickets/entity"
+)
+
+func TestScheduler_PublishDue(t *testing.T) {
+	ctx := context.Background()
+	container, url := dbutils.StartPostgresContainer()
+	defer container.Terminate(ctx)
+
+	t.Setenv("POSTGRES_URL", url)
+	db := dbutils.GetDb(t)
+
+	dueEvent := entity.InternalVipBundleStepTimedOut{
+		Header:      entity.NewEventHeader(),
+		VipBundleID: uuid.NewString(),
+		Step:        entity.VipBundleStepInboundFlight,
+	}
+	futureEvent := entity.InternalVipBundleStepTimedOut{
+		Header:      entity.NewEventHeader(),
+		VipBundleID: uuid.NewString(),
+		Step:        entity.VipBundleStepTaxi,
+	}
+
+	err := NewScheduler(db).Schedule(ctx, dueEvent, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	err = NewScheduler(db).Schedule(ctx, futureEvent, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	// scheduled messages are stored, so they are published by a scheduler created after a restart
+	restarted := NewScheduler(db)
+
+	published, err := restarted.PublishDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	published, err = restarted.PublishDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, published, "due messages should be published only once")
+
+	var pending []string
+	err = db.SelectContext(ctx, &pending, `
+		SELECT payload->'vip_bundle_id' #>> '{}' FROM (
+			SELECT convert_from(payload, 'UTF8')::jsonb AS payload
+			FROM scheduled_messages
+		) AS scheduled
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{futureEvent.VipBundleID}, pending, "published messages should be deleted")
+}
@@ -0,0 +1,101 @@
+package upcasting
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"tickets/entity"
+)
+
+// NewEventsRegistry returns the registry with upcasters of all older versions of the service's events.
+// When an event changes, register the upcaster from its previous version here,
+// so neither handlers nor data lake replay have to handle the previous version.
+func NewEventsRegistry() *Registry {
+	r := NewRegistry()
+
+	RegisterFunc(r, "BookingMade", 0, func(e bookingMade_v0) (entity.BookingMade_v1, error) {
+		return entity.BookingMade_v1{
+			Header:          e.Header,
+			NumberOfTickets: e.NumberOfTickets,
+			BookingID:       e.BookingID.String(),
+			CustomerEmail:   e.CustomerEmail,
+			ShowID:          e.ShowId.String(),
+		}, nil
+	})
+	RegisterFunc(r, "TicketBookingConfirmed", 0, func(e ticketBookingConfirmed_v0) (entity.TicketBookingConfirmed_v1, error) {
+		return entity.TicketBookingConfirmed_v1{
+			Header:        e.Header,
+			TicketID:      e.TicketID,
+			CustomerEmail: e.CustomerEmail,
+			Price:         e.Price,
+			BookingID:     e.BookingID,
+		}, nil
+	})
+	RegisterFunc(r, "TicketReceiptIssued", 0, func(e ticketReceiptIssued_v0) (entity.TicketReceiptIssued_v1, error) {
+		return entity.TicketReceiptIssued_v1{
+			Header:        e.Header,
+			TicketID:      e.TicketID,
+			ReceiptNumber: e.ReceiptNumber,
+			IssuedAt:      e.IssuedAt,
+		}, nil
+	})
+	RegisterFunc(r, "TicketPrinted", 0, func(e ticketPrinted_v0) (entity.TicketPrinted_v1, error) {
+		return entity.TicketPrinted_v1{
+			Header:   e.Header,
+			TicketID: e.TicketID,
+			FileName: e.FileName,
+		}, nil
+	})
+	RegisterFunc(r, "TicketRefunded", 0, func(e ticketRefunded_v0) (entity.TicketRefunded_v1, error) {
+		return entity.TicketRefunded_v1{
+			Header:   e.Header,
+			TicketID: e.TicketID,
+		}, nil
+	})
+
+	return r
+}
+
+type bookingMade_v0 struct {
+	Header entity.EventHeader `json:"header"`
+
+	NumberOfTickets int `json:"number_of_tickets"`
+
+	BookingID uuid.UUID `json:"booking_id"`
+
+	CustomerEmail string    `json:"customer_email"`
+	ShowId        uuid.UUID `json:"show_id"`
+}
+
+type ticketBookingConfirmed_v0 struct {
+	Header entity.EventHeader `json:"header"`
+
+	TicketID      string       `json:"ticket_id"`
+	CustomerEmail string       `json:"customer_email"`
+	Price         entity.Money `json:"price"`
+
+	BookingID string `json:"booking_id"`
+}
+
+type ticketReceiptIssued_v0 struct {
+	Header entity.EventHeader `json:"header"`
+
+	TicketID      string `json:"ticket_id"`
+	ReceiptNumber string `json:"receipt_number"`
+
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+type ticketPrinted_v0 struct {
+	Header entity.EventHeader `json:"header"`
+
+	TicketID string `json:"ticket_id"`
+	FileName string `json:"file_name"`
+}
+
+type ticketRefunded_v0 struct {
+	Header entity.EventHeader `json:"header"`
+
+	TicketID string `json:"ticket_id"`
+}
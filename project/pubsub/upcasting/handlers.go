@@ -0,0 +1,46 @@
+package upcasting
+
+import (
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+)
+
+// olderVersionHandler receives an older version of the event of the wrapped handler.
+// Events are published to topics named after their version, so each older version needs its own subscription,
+// the upcasting Marshaler upcasts the received events to the version the wrapped handler expects.
+type olderVersionHandler struct {
+	cqrs.EventHandler
+
+	eventName string
+}
+
+// HandlerName is unique per version, so each version has its own router handler and consumer group.
+func (h olderVersionHandler) HandlerName() string {
+	return h.EventHandler.HandlerName() + "." + h.eventName
+}
+
+// OlderVersionsHandlers returns handlers which subscribe to the older versions of events of the handlers,
+// so events which were published in older versions reach handlers of their latest version.
+// The marshaler must name events the same way they are published.
+func (r *Registry) OlderVersionsHandlers(marshaler cqrs.CommandEventMarshaler, handlers ...cqrs.EventHandler) []cqrs.EventHandler {
+	var olderHandlers []cqrs.EventHandler
+	for _, h := range handlers {
+		for _, name := range r.OlderNames(marshaler.Name(h.NewEvent())) {
+			olderHandlers = append(olderHandlers, olderVersionHandler{
+				EventHandler: h,
+				eventName:    name,
+			})
+		}
+	}
+
+	return olderHandlers
+}
+
+// SubscribeEventName returns the name of the event version the handler subscribes to,
+// it should be used to generate subscribe topics instead of params.EventName.
+func SubscribeEventName(params cqrs.EventProcessorGenerateSubscribeTopicParams) string {
+	if h, ok := params.EventHandler.(olderVersionHandler); ok {
+		return h.eventName
+	}
+
+	return params.EventName
+}
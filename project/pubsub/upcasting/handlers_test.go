@@ -0,0 +1,90 @@
+package upcasting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_OlderNames(t *testing.T) {
+	r := newTicketSoldRegistry()
+
+	assert.Equal(t, []string{"TicketSold_v0", "TicketSold_v1"}, r.OlderNames("TicketSold_v2"))
+	assert.Empty(t, r.OlderNames("TicketPrinted_v0"))
+}
+
+func TestRegistry_OlderVersionsHandlers_receive_published_older_versions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	logger := watermill.NopLogger{}
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, logger)
+	jsonMarshaler := cqrs.JSONMarshaler{GenerateName: cqrs.StructName}
+	registry := newTicketSoldRegistry()
+
+	router, err := message.NewRouter(message.RouterConfig{}, logger)
+	require.NoError(t, err)
+
+	processor, err := cqrs.NewEventProcessorWithConfig(router, cqrs.EventProcessorConfig{
+		GenerateSubscribeTopic: func(params cqrs.EventProcessorGenerateSubscribeTopicParams) (string, error) {
+			return "events." + SubscribeEventName(params), nil
+		},
+		SubscriberConstructor: func(params cqrs.EventProcessorSubscriberConstructorParams) (message.Subscriber, error) {
+			return pubSub, nil
+		},
+		Marshaler: NewMarshaler(jsonMarshaler, registry),
+		Logger:    logger,
+	})
+	require.NoError(t, err)
+
+	received := make(chan TicketSold_v2, 3)
+	handler := cqrs.NewEventHandler("OnTicketSold", func(ctx context.Context, event *TicketSold_v2) error {
+		received <- *event
+		return nil
+	})
+
+	handlers := append([]cqrs.EventHandler{handler}, registry.OlderVersionsHandlers(jsonMarshaler, handler)...)
+	require.Len(t, handlers, 3)
+	require.NoError(t, processor.AddHandlers(handlers...))
+
+	go func() {
+		_ = router.Run(ctx)
+	}()
+	<-router.Running()
+
+	eventBus, err := cqrs.NewEventBusWithConfig(pubSub, cqrs.EventBusConfig{
+		GeneratePublishTopic: func(params cqrs.GenerateEventPublishTopicParams) (string, error) {
+			return "events." + params.EventName, nil
+		},
+		Marshaler: jsonMarshaler,
+		Logger:    logger,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, eventBus.Publish(ctx, TicketSold_v0{TicketID: "1", Name: "John Doe"}))
+	require.NoError(t, eventBus.Publish(ctx, TicketSold_v1{TicketID: "2", FirstName: "Jane", LastName: "Doe"}))
+	require.NoError(t, eventBus.Publish(ctx, TicketSold_v2{TicketID: "3", FirstName: "Jim", LastName: "Doe", Channel: "online"}))
+
+	var events []TicketSold_v2
+	for i := 0; i < 3; i++ {
+		select {
+		case event := <-received:
+			events = append(events, event)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received only %d of 3 events", len(events))
+		}
+	}
+
+	assert.ElementsMatch(t, []TicketSold_v2{
+		{TicketID: "1", FirstName: "John", LastName: "Doe", Channel: "box_office"},
+		{TicketID: "2", FirstName: "Jane", LastName: "Doe", Channel: "box_office"},
+		{TicketID: "3", FirstName: "Jim", LastName: "Doe", Channel: "online"},
+	}, events)
+}
@@ -0,0 +1,50 @@
+package upcasting
+
+import (
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// Marshaler upcasts events published in older versions, so handlers of the latest version receive them too.
+// Events are marshaled with the wrapped marshaler unchanged.
+type Marshaler struct {
+	cqrs.CommandEventMarshaler
+
+	registry *Registry
+}
+
+func NewMarshaler(marshaler cqrs.CommandEventMarshaler, registry *Registry) Marshaler {
+	if marshaler == nil {
+		panic("marshaler is nil")
+	}
+	if registry == nil {
+		panic("registry is nil")
+	}
+
+	return Marshaler{
+		CommandEventMarshaler: marshaler,
+		registry:              registry,
+	}
+}
+
+// NameFromMessage returns the name of the latest version of the event.
+func (m Marshaler) NameFromMessage(msg *message.Message) string {
+	return m.registry.LatestName(m.CommandEventMarshaler.NameFromMessage(msg))
+}
+
+func (m Marshaler) Unmarshal(msg *message.Message, v interface{}) error {
+	name := m.CommandEventMarshaler.NameFromMessage(msg)
+
+	upcastedName, payload, err := m.registry.Upcast(name, msg.Payload)
+	if err != nil {
+		return err
+	}
+	if upcastedName == name {
+		return m.CommandEventMarshaler.Unmarshal(msg, v)
+	}
+
+	upcastedMsg := msg.Copy()
+	upcastedMsg.Payload = payload
+
+	return m.CommandEventMarshaler.Unmarshal(upcastedMsg, v)
+}
@@ -0,0 +1,125 @@
+package upcasting
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Upcaster transforms the JSON payload of an event to the next version of the event.
+type Upcaster func(payload []byte) ([]byte, error)
+
+// Registry upcasts events published in older versions to their latest version.
+//
+// Event names are versioned with a suffix, like BookingMade_v1. Each upcaster upgrades an event by one version,
+// so an event is upgraded to its latest version by chaining all upcasters registered for the versions after it.
+type Registry struct {
+	// upcasters are keyed by the versioned name of the event they upgrade
+	upcasters map[string]Upcaster
+}
+
+func NewRegistry() *Registry {
+	return &Registry{upcasters: map[string]Upcaster{}}
+}
+
+// Register adds the upcaster of the event from fromVersion to fromVersion+1.
+// It panics when an upcaster of the same event version is already registered.
+func (r *Registry) Register(eventName string, fromVersion int, upcaster Upcaster) {
+	name := VersionedName(eventName, fromVersion)
+	if _, ok := r.upcasters[name]; ok {
+		panic(fmt.Sprintf("upcaster of %s is already registered", name))
+	}
+
+	r.upcasters[name] = upcaster
+}
+
+// RegisterFunc adds the upcaster of the event from fromVersion to fromVersion+1,
+// which maps the unmarshaled old version of the event to the new one.
+func RegisterFunc[From any, To any](r *Registry, eventName string, fromVersion int, upcast func(From) (To, error)) {
+	r.Register(eventName, fromVersion, func(payload []byte) ([]byte, error) {
+		var from From
+		if err := json.Unmarshal(payload, &from); err != nil {
+			return nil, fmt.Errorf("could not unmarshal %s: %w", VersionedName(eventName, fromVersion), err)
+		}
+
+		to, err := upcast(from)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(to)
+	})
+}
+
+// Upcast returns the name and the payload of the latest version of the event.
+// Events without registered upcasters are returned unchanged.
+func (r *Registry) Upcast(name string, payload []byte) (string, []byte, error) {
+	for {
+		upcaster, ok := r.upcasters[name]
+		if !ok {
+			return name, payload, nil
+		}
+
+		nextName, err := nextVersionName(name)
+		if err != nil {
+			return "", nil, err
+		}
+
+		payload, err = upcaster(payload)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not upcast %s to %s: %w", name, nextName, err)
+		}
+
+		name = nextName
+	}
+}
+
+// LatestName returns the name of the latest version of the event, without upcasting its payload.
+func (r *Registry) LatestName(name string) string {
+	for {
+		if _, ok := r.upcasters[name]; !ok {
+			return name
+		}
+
+		nextName, err := nextVersionName(name)
+		if err != nil {
+			return name
+		}
+
+		name = nextName
+	}
+}
+
+// VersionedName returns the name of the event version, like BookingMade_v1.
+func VersionedName(eventName string, version int) string {
+	return eventName + "_v" + strconv.Itoa(version)
+}
+
+func nextVersionName(name string) (string, error) {
+	i := strings.LastIndex(name, "_v")
+	if i == -1 {
+		return "", fmt.Errorf("event name %s has no version", name)
+	}
+
+	version, err := strconv.Atoi(name[i+len("_v"):])
+	if err != nil {
+		return "", fmt.Errorf("event name %s has invalid version: %w", name, err)
+	}
+
+	return VersionedName(name[:i], version+1), nil
+}
+
+// OlderNames returns the names of all older versions of the event which are upcasted to its latest version.
+func (r *Registry) OlderNames(latestName string) []string {
+	var names []string
+	for name := range r.upcasters {
+		if name != latestName && r.LatestName(name) == latestName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,179 @@
+package upcasting
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tickets/entity"
+)
+
+type TicketSold_v0 struct {
+	TicketID string `json:"ticket_id"`
+	Name     string `json:"name"`
+}
+
+type TicketSold_v1 struct {
+	TicketID  string `json:"ticket_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type TicketSold_v2 struct {
+	TicketID  string `json:"ticket_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Channel   string `json:"channel"`
+}
+
+func newTicketSoldRegistry() *Registry {
+	r := NewRegistry()
+
+	// registered out of order on purpose, upcasters are chained by versions
+	RegisterFunc(r, "TicketSold", 1, func(e TicketSold_v1) (TicketSold_v2, error) {
+		return TicketSold_v2{
+			TicketID:  e.TicketID,
+			FirstName: e.FirstName,
+			LastName:  e.LastName,
+			Channel:   "box_office",
+		}, nil
+	})
+	RegisterFunc(r, "TicketSold", 0, func(e TicketSold_v0) (TicketSold_v1, error) {
+		firstName, lastName, _ := strings.Cut(e.Name, " ")
+
+		return TicketSold_v1{
+			TicketID:  e.TicketID,
+			FirstName: firstName,
+			LastName:  lastName,
+		}, nil
+	})
+
+	return r
+}
+
+func TestRegistry_Upcast(t *testing.T) {
+	r := newTicketSoldRegistry()
+
+	testCases := []struct {
+		Name    string
+		Event   any
+		Version int
+	}{
+		{
+			Name:    "chained_from_v0",
+			Event:   TicketSold_v0{TicketID: "1", Name: "John Doe"},
+			Version: 0,
+		},
+		{
+			Name:    "from_v1",
+			Event:   TicketSold_v1{TicketID: "1", FirstName: "John", LastName: "Doe"},
+			Version: 1,
+		},
+		{
+			Name:    "latest_version",
+			Event:   TicketSold_v2{TicketID: "1", FirstName: "John", LastName: "Doe", Channel: "box_office"},
+			Version: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			payload, err := json.Marshal(tc.Event)
+			require.NoError(t, err)
+
+			name, upcasted, err := r.Upcast(VersionedName("TicketSold", tc.Version), payload)
+			require.NoError(t, err)
+			assert.Equal(t, "TicketSold_v2", name)
+
+			var event TicketSold_v2
+			require.NoError(t, json.Unmarshal(upcasted, &event))
+			assert.Equal(t, TicketSold_v2{
+				TicketID:  "1",
+				FirstName: "John",
+				LastName:  "Doe",
+				Channel:   "box_office",
+			}, event)
+		})
+	}
+}
+
+func TestRegistry_Upcast_unknown_event(t *testing.T) {
+	r := newTicketSoldRegistry()
+
+	payload := []byte(`{"ticket_id":"1"}`)
+
+	name, upcasted, err := r.Upcast("TicketPrinted_v0", payload)
+	require.NoError(t, err)
+	assert.Equal(t, "TicketPrinted_v0", name)
+	assert.Equal(t, payload, upcasted)
+
+	assert.Equal(t, "TicketSold_v2", r.LatestName("TicketSold_v0"))
+	assert.Equal(t, "TicketPrinted_v0", r.LatestName("TicketPrinted_v0"))
+}
+
+func TestRegistry_Register_duplicate(t *testing.T) {
+	r := newTicketSoldRegistry()
+
+	assert.Panics(t, func() {
+		r.Register("TicketSold", 0, func(payload []byte) ([]byte, error) {
+			return payload, nil
+		})
+	})
+}
+
+func TestMarshaler_upcasts_older_versions(t *testing.T) {
+	jsonMarshaler := cqrs.JSONMarshaler{GenerateName: cqrs.StructName}
+	m := NewMarshaler(jsonMarshaler, newTicketSoldRegistry())
+
+	msg, err := jsonMarshaler.Marshal(TicketSold_v0{TicketID: "1", Name: "John Doe"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "TicketSold_v2", m.NameFromMessage(msg))
+	assert.Equal(t, m.Name(&TicketSold_v2{}), m.NameFromMessage(msg))
+
+	var event TicketSold_v2
+	require.NoError(t, m.Unmarshal(msg, &event))
+	assert.Equal(t, "John", event.FirstName)
+	assert.Equal(t, "box_office", event.Channel)
+
+	// the original message is kept as it was published
+	var original TicketSold_v0
+	require.NoError(t, json.Unmarshal(msg.Payload, &original))
+	assert.Equal(t, "John Doe", original.Name)
+}
+
+func TestNewEventsRegistry_BookingMade_v0(t *testing.T) {
+	r := NewEventsRegistry()
+
+	bookingID := uuid.New()
+	showID := uuid.New()
+
+	payload, err := json.Marshal(bookingMade_v0{
+		Header:          entity.NewEventHeader(),
+		NumberOfTickets: 2,
+		BookingID:       bookingID,
+		CustomerEmail:   "foo@bar.com",
+		ShowId:          showID,
+	})
+	require.NoError(t, err)
+
+	msg := message.NewMessage(watermill.NewUUID(), payload)
+	msg.Metadata.Set("name", "BookingMade_v0")
+
+	m := NewMarshaler(cqrs.JSONMarshaler{GenerateName: cqrs.StructName}, r)
+	assert.Equal(t, "BookingMade_v1", m.NameFromMessage(msg))
+
+	var event entity.BookingMade_v1
+	require.NoError(t, m.Unmarshal(msg, &event))
+	assert.Equal(t, bookingID.String(), event.BookingID)
+	assert.Equal(t, showID.String(), event.ShowID)
+	assert.Equal(t, 2, event.NumberOfTickets)
+	assert.Equal(t, "foo@bar.com", event.CustomerEmail)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+)
+
+const scheduledMessagesPublishingInterval = time.Second
+
+type scheduledMessagesPublisher interface {
+	PublishDue(ctx context.Context) (int, error)
+}
+
+// publishScheduledMessages periodically publishes scheduled messages which are due until the context is canceled.
+func publishScheduledMessages(ctx context.Context, publisher scheduledMessagesPublisher) {
+	ticker := time.NewTicker(scheduledMessagesPublishingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := publisher.PublishDue(ctx)
+			if err != nil {
+				log.FromContext(ctx).WithError(err).Error("failed to publish scheduled messages")
+				continue
+			}
+			if published > 0 {
+				log.FromContext(ctx).Infof("published %d scheduled messages", published)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+)
+
+const seatHoldsExpirationInterval = time.Second
+
+type seatHoldsExpirer interface {
+	ExpireHolds(ctx context.Context) (int, error)
+}
+
+// expireSeatHolds periodically releases seats of expired holds until the context is canceled.
+func expireSeatHolds(ctx context.Context, expirer seatHoldsExpirer) {
+	ticker := time.NewTicker(seatHoldsExpirationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := expirer.ExpireHolds(ctx)
+			if err != nil {
+				log.FromContext(ctx).WithError(err).Error("failed to expire seat holds")
+				continue
+			}
+			if expired > 0 {
+				log.FromContext(ctx).Infof("expired %d seat holds", expired)
+			}
+		}
+	}
+}
@@ -20,8 +20,14 @@ import (
 	dbLib "tickets/db"
 	"tickets/db/bookings"
 	dl "tickets/db/data_lake"
+	"tickets/db/dead_nation_cancellations"
+	"tickets/db/idempotency_keys"
+	"tickets/db/processed_commands"
+	"tickets/db/promotions"
 	"tickets/db/read_model_ops_bookings"
+	"tickets/db/show_cancellations"
 	"tickets/db/shows"
+	"tickets/db/ticket_refunds"
 	"tickets/db/tickets"
 	"tickets/db/vip_bundle_repository"
 	"tickets/entity"
@@ -32,6 +38,8 @@ import (
 	"tickets/pubsub/command"
 	"tickets/pubsub/event"
 	"tickets/pubsub/outbox"
+	"tickets/pubsub/scheduler"
+	"tickets/pubsub/upcasting"
 	"tickets/tracing"
 )
 
@@ -53,7 +61,10 @@ type Service struct {
 	watermillRouter *message.Router
 	httpServer      *http.Server
 	opsReadModel    read_model_ops_bookings.OpsBookingReadModel
+	bookingsRepo    *bookings.PostgresRepository
 	dataLake        dl.DataLake
+	upcasters       *upcasting.Registry
+	scheduler       *scheduler.Scheduler
 	traceProvider   *tracesdk.TracerProvider
 }
 
@@ -67,6 +78,8 @@ func New(
 	deadNationService event.DeadNationService,
 	paymentService event.PaymentService,
 	transService command.TransportationService,
+	seatHoldTTL time.Duration,
+	waitlistOfferTTL time.Duration,
 	traceProvider *tracesdk.TracerProvider,
 ) Service {
 	var redisPublisher message.Publisher
@@ -84,24 +97,31 @@ func New(
 	showsRepo := shows.NewPostgresRepository(db)
 	bookingsRepo := bookings.NewPostgresRepository(db)
 	vipBundleRepo := vip_bundle_repository.NewPostgresRepository(db)
+	ticketRefundsRepo := ticket_refunds.NewPostgresRepository(db)
+	showCancellationsRepo := show_cancellations.NewPostgresRepository(db)
 	opsReadModel := read_model_ops_bookings.NewOpsBookingReadModel(db, eventBus)
+	opsUpdatesFeed := read_model_ops_bookings.NewUpdatesFeed()
+
+	commandBus, err := bus.NewCommandBus(redisPublisher)
+	if err != nil {
+		panic(fmt.Errorf("failed to create command bus: %w", err))
+	}
 
 	eventsHandler := event.NewHandler(
 		eventBus,
+		commandBus,
 		spreadsheetsService,
 		receiptsService,
 		fileService,
 		deadNationService,
+		dead_nation_cancellations.NewPostgresRepository(db),
 		paymentService,
 		ticketsRepo,
 		showsRepo,
+		bookingsRepo,
+		waitlistOfferTTL,
 	)
 
-	commandBus, err := bus.NewCommandBus(redisPublisher)
-	if err != nil {
-		panic(fmt.Errorf("failed to create command bus: %w", err))
-	}
-
 	commandsHandler := command.NewHandler(
 		eventBus,
 		receiptsService,
@@ -109,11 +129,14 @@ func New(
 		transService,
 		showsRepo,
 		bookingsRepo,
+		ticketRefundsRepo,
+		ticketsRepo,
 	)
 
 	postgresSubscriber := outbox.NewPostgresSubscriber(db.DB, watermillLogger)
-	eventProcessorConfig := event.NewProcessorConfig(redisClient, watermillLogger)
-	commandProcessorConfig := command.NewProcessorConfig(redisClient, watermillLogger)
+	upcasters := upcasting.NewEventsRegistry()
+	eventProcessorConfig := event.NewProcessorConfig(redisClient, upcasters, watermillLogger)
+	commandProcessorConfig := command.NewProcessorConfig(redisClient, processed_commands.NewPostgresRepository(db), watermillLogger)
 
 	redisSubscriber, err := redisstream.NewSubscriber(redisstream.SubscriberConfig{
 		Client: redisClient,
@@ -123,18 +146,25 @@ func New(
 	}
 
 	dataLake := dl.NewDataLake(db)
-	vipBundleProcessManager := entity.NewVipBundleProcessManager(commandBus, eventBus, vipBundleRepo)
+	messagesScheduler := scheduler.NewScheduler(db)
+	vipBundleProcessManager := entity.NewVipBundleProcessManager(commandBus, eventBus, messagesScheduler, vipBundleRepo)
+	showCancellationProcessManager := entity.NewShowCancellationProcessManager(commandBus, showCancellationsRepo, ticketsRepo)
 	watermillRouter, err := pubsub.NewWatermillRouter(
 		postgresSubscriber,
 		redisPublisher,
 		redisSubscriber,
 		eventProcessorConfig,
+		upcasters,
 		eventsHandler,
 		commandProcessorConfig,
 		commandsHandler,
 		opsReadModel,
+		opsUpdatesFeed,
+		ticketRefundsRepo,
+		bookingsRepo,
 		dataLake,
 		vipBundleProcessManager,
+		showCancellationProcessManager,
 		watermillLogger,
 	)
 	if err != nil {
@@ -144,13 +174,20 @@ func New(
 	httpServer := http.NewServer(
 		addr,
 		eventBus,
-		commandBus,
+		outbox.NewEventPublisher(db),
 		spreadsheetsService,
+		fileService,
 		ticketsRepo,
+		ticketRefundsRepo,
 		showsRepo,
+		showCancellationsRepo,
 		bookingsRepo,
 		opsReadModel,
+		opsUpdatesFeed,
 		vipBundleRepo,
+		promotions.NewPostgresRepository(db),
+		idempotency_keys.NewPostgresRepository(db),
+		seatHoldTTL,
 	)
 
 	return Service{
@@ -158,7 +195,10 @@ func New(
 		watermillRouter,
 		httpServer,
 		opsReadModel,
+		bookingsRepo,
 		dataLake,
+		upcasters,
+		messagesScheduler,
 		traceProvider,
 	}
 }
@@ -183,7 +223,7 @@ func (s Service) Run(ctx context.Context) error {
 	})
 
 	g.Go(func() error {
-		err := migrations.MigrateReadModel(ctx, s.dataLake, s.opsReadModel)
+		err := migrations.MigrateReadModel(ctx, s.dataLake, s.upcasters, s.opsReadModel)
 		if err != nil {
 			log.FromContext(ctx).Errorf("failed to migrate read model: %s", err)
 		}
@@ -195,6 +235,16 @@ func (s Service) Run(ctx context.Context) error {
 		return s.traceProvider.Shutdown(context.Background())
 	})
 
+	g.Go(func() error {
+		expireSeatHolds(ctx, s.bookingsRepo)
+		return nil
+	})
+
+	g.Go(func() error {
+		publishScheduledMessages(ctx, s.scheduler)
+		return nil
+	})
+
 	g.Go(func() error {
 		return s.watermillRouter.Run(ctx)
 	})
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -15,11 +16,13 @@ import (
 	"github.com/lithammer/shortuuid/v3"
 	"github.com/redis/go-redis/v9"
 	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/goleak"
 
+	"tickets/db/dead_nation_cancellations"
 	"tickets/db/tickets"
 	"tickets/db/vip_bundle_repository"
 	"tickets/entity"
@@ -56,7 +59,8 @@ func TestComponent(t *testing.T) {
 	filesClient := &gateway.FilesMock{}
 	deadNationClient := &gateway.DeadNationMock{}
 	paymentClient := &gateway.PaymentMock{}
-	transClient := &gateway.TransportationMock{}
+	soldOutFlightID := uuid.NewString()
+	transClient := &gateway.TransportationMock{SoldOutFlightIDs: []string{soldOutFlightID}}
 	traceProvider := trace.NewTracerProvider()
 
 	vbRepo := vip_bundle_repository.NewPostgresRepository(dbconn)
@@ -72,6 +76,8 @@ func TestComponent(t *testing.T) {
 			deadNationClient,
 			paymentClient,
 			transClient,
+			time.Minute,
+			time.Minute,
 			traceProvider,
 		)
 		assert.NoError(t, svc.Run(ctx))
@@ -98,6 +104,7 @@ func TestComponent(t *testing.T) {
 	assertTicketPrinted(t, filesClient, ticket)
 	assertRowToSheetAdded(t, spreadsheetsClient, ticket, "tickets-to-print")
 	assertTicketStoredInRepository(t, dbconn, ticket)
+	assertTicketFileNotFound(t, uuid.NewString())
 
 	sendTicketsStatus(t, TicketsStatusRequest{Tickets: []TicketStatus{
 		{
@@ -127,6 +134,35 @@ func TestComponent(t *testing.T) {
 	err = json.NewDecoder(bookResp.Body).Decode(&bookingID)
 	require.NoError(t, err)
 
+	bookingResp := getBooking(t, bookingID.BookingID, "test@test.io")
+	require.Equal(t, http.StatusOK, bookingResp.StatusCode)
+	bookingResp = getBooking(t, bookingID.BookingID, "other@test.io")
+	assert.Equal(t, http.StatusNotFound, bookingResp.StatusCode)
+
+	// retried booking with the same idempotency key is replayed instead of booking seats again
+	bookingIdempotencyKey := uuid.NewString()
+	idempotentBookingRequest := postBookTicketsRequest{
+		ShowID:          showID,
+		NumberOfTickets: 1,
+		CustomerEmail:   "test@test.io",
+	}
+	firstBookResp := bookTicketsWithIdempotencyKey(t, idempotentBookingRequest, bookingIdempotencyKey)
+	require.Equal(t, http.StatusCreated, firstBookResp.StatusCode)
+	retriedBookResp := bookTicketsWithIdempotencyKey(t, idempotentBookingRequest, bookingIdempotencyKey)
+	require.Equal(t, http.StatusCreated, retriedBookResp.StatusCode)
+
+	firstBooking, retriedBooking := postBookTicketsResponse{}, postBookTicketsResponse{}
+	require.NoError(t, json.NewDecoder(firstBookResp.Body).Decode(&firstBooking))
+	require.NoError(t, json.NewDecoder(retriedBookResp.Body).Decode(&retriedBooking))
+	assert.Equal(t, firstBooking.BookingID, retriedBooking.BookingID)
+
+	idempotentBookingRequest.NumberOfTickets = 2
+	mismatchedBookResp := bookTicketsWithIdempotencyKey(t, idempotentBookingRequest, bookingIdempotencyKey)
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatchedBookResp.StatusCode)
+
+	resp := cancelBooking(t, firstBooking.BookingID)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
 	// overbooking
 	bookResp = bookTickets(t, postBookTicketsRequest{
 		ShowID:          showID,
@@ -135,11 +171,49 @@ func TestComponent(t *testing.T) {
 	})
 	assert.Equal(t, http.StatusBadRequest, bookResp.StatusCode)
 
-	// refund
-	resp := sentTicketRefund(t, bookingID.BookingID)
+	show := getShow(t, showID)
+	assert.Equal(t, 5, show.NumberOfTickets)
+	assert.Equal(t, 2, show.AvailableTickets)
+
+	// booking cancellation
+	cancelBookingResp := bookTickets(t, postBookTicketsRequest{
+		ShowID:          showID,
+		NumberOfTickets: 2,
+		CustomerEmail:   "test@test.io",
+	})
+	require.Equal(t, http.StatusCreated, cancelBookingResp.StatusCode)
+
+	bookingToCancel := postBookTicketsResponse{}
+	err = json.NewDecoder(cancelBookingResp.Body).Decode(&bookingToCancel)
+	require.NoError(t, err)
+
+	resp = cancelBooking(t, bookingToCancel.BookingID)
 	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
-	assertVoidReceipt(t, receiptsClient, ticket.TicketID)
-	assertRefundIssued(t, paymentClient, ticket.TicketID)
+	assert.Equal(t, 2, getShow(t, showID).AvailableTickets)
+	assertDeadNationManualCancellationRequested(t, dbconn, bookingToCancel.BookingID)
+
+	// refund
+	ticketToRefund := TicketStatus{
+		TicketID:  uuid.NewString(),
+		Status:    "confirmed",
+		Price:     Money{Amount: "50", Currency: "USD"},
+		Email:     "test@test.io",
+		BookingID: uuid.NewString(),
+	}
+	sendTicketsStatus(t, TicketsStatusRequest{Tickets: []TicketStatus{ticketToRefund}}, uuid.NewString())
+	assertTicketStoredInRepository(t, dbconn, ticketToRefund)
+
+	resp = sentTicketRefund(t, uuid.NewString())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// double click doesn't refund the ticket twice
+	for i := 0; i < 2; i++ {
+		resp = sentTicketRefund(t, ticketToRefund.TicketID)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+	assertVoidReceipt(t, receiptsClient, ticketToRefund.TicketID)
+	assertRefundIssued(t, paymentClient, ticketToRefund.TicketID)
+	assertTicketRefundCompleted(t, ticketToRefund.TicketID)
 
 	// vip bundle
 	vb := vipBundleRequest{
@@ -157,6 +231,88 @@ func TestComponent(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusCreated, resp.StatusCode)
 	assertVipBundleSuccessfullyBooked(t, vbRepo, vbResp)
+
+	vbStatus := getVipBundleStatus(t, vbResp.VipBundleID)
+	assert.Equal(t, "finalized", vbStatus.Status)
+	assert.Empty(t, vbStatus.FailureReason)
+	for _, step := range vbStatus.Timeline {
+		assert.Equal(t, "completed", step.Status, "step %s not completed", step.Step)
+	}
+
+	// vip bundle, the sold out inbound flight is replaced with its alternative
+	alternativeInboundFlightID := uuid.NewString()
+	alternativeVb := vipBundleRequest{
+		CustomerEmail:               "test2@test.io",
+		InboundFlightID:             soldOutFlightID,
+		AlternativeInboundFlightIDs: []string{alternativeInboundFlightID},
+		NumberOfTickets:             1,
+		Passengers:                  []string{"test2"},
+		ReturnFlightID:              uuid.NewString(),
+		ShowID:                      showID,
+	}
+
+	resp = sendBookVipBundle(t, alternativeVb)
+	var alternativeVbResp vipBundleResponse
+	err = json.NewDecoder(resp.Body).Decode(&alternativeVbResp)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assertVipBundleSuccessfullyBooked(t, vbRepo, alternativeVbResp)
+
+	alternativeVbStatus := getVipBundleStatus(t, alternativeVbResp.VipBundleID)
+	assert.Equal(t, "finalized", alternativeVbStatus.Status)
+	assert.Empty(t, alternativeVbStatus.FailureReason)
+	if assert.Len(t, alternativeVbStatus.FlightAttempts, 3) {
+		assert.Equal(t, soldOutFlightID, alternativeVbStatus.FlightAttempts[0].FlightID)
+		assert.Equal(t, "failed", alternativeVbStatus.FlightAttempts[0].Status)
+		assert.Equal(t, alternativeInboundFlightID, alternativeVbStatus.FlightAttempts[1].FlightID)
+		assert.Equal(t, "booked", alternativeVbStatus.FlightAttempts[1].Status)
+	}
+
+	// show update and cancellation
+	showToCancelID := sendPostShow(t, postShowsRequest{
+		DeadNationID:    uuid.NewString(),
+		NumberOfTickets: 5,
+		StartTime:       time.Now().Add(time.Hour),
+		Title:           "test",
+		Venue:           "test",
+	})
+
+	bookResp = bookTickets(t, postBookTicketsRequest{
+		ShowID:          showToCancelID,
+		NumberOfTickets: 2,
+		CustomerEmail:   "test@test.io",
+	})
+	require.Equal(t, http.StatusCreated, bookResp.StatusCode)
+	canceledShowBooking := postBookTicketsResponse{}
+	require.NoError(t, json.NewDecoder(bookResp.Body).Decode(&canceledShowBooking))
+
+	resp = sendPutShow(t, showToCancelID, map[string]any{"number_of_tickets": 1})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "capacity should not drop below booked seats")
+	resp = sendPutShow(t, showToCancelID, map[string]any{"number_of_tickets": 3, "venue": "new venue"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, getShow(t, showToCancelID).AvailableTickets)
+
+	canceledShowTicket := TicketStatus{
+		TicketID:  uuid.NewString(),
+		Status:    "confirmed",
+		Price:     Money{Amount: "50", Currency: "USD"},
+		Email:     "test@test.io",
+		BookingID: canceledShowBooking.BookingID,
+	}
+	sendTicketsStatus(t, TicketsStatusRequest{Tickets: []TicketStatus{canceledShowTicket}}, uuid.NewString())
+	assertTicketStoredInRepository(t, dbconn, canceledShowTicket)
+
+	resp = sendCancelShow(t, showToCancelID)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assertShowCancellationCompleted(t, showToCancelID, 1)
+	assertTicketRefundCompleted(t, canceledShowTicket.TicketID)
+
+	bookResp = bookTickets(t, postBookTicketsRequest{
+		ShowID:          showToCancelID,
+		NumberOfTickets: 1,
+		CustomerEmail:   "test@test.io",
+	})
+	assert.Equal(t, http.StatusConflict, bookResp.StatusCode, "canceled show should not be booked")
 }
 
 func assertVipBundleSuccessfullyBooked(t *testing.T, vipBundleRepo entity.VipBundleRepository, resp vipBundleResponse) {
@@ -179,6 +335,21 @@ func assertVipBundleSuccessfullyBooked(t *testing.T, vipBundleRepo entity.VipBun
 	)
 }
 
+func assertDeadNationManualCancellationRequested(t *testing.T, db *sqlx.DB, bookingID string) {
+	cancellationsRepo := dead_nation_cancellations.NewPostgresRepository(db)
+
+	assert.EventuallyWithT(
+		t,
+		func(t *assert.CollectT) {
+			pending, err := cancellationsRepo.Pending(context.Background())
+			assert.NoError(t, err)
+			assert.Contains(t, pending, bookingID, "booking %s not recorded for manual cancellation in Dead Nation", bookingID)
+		},
+		10*time.Second,
+		100*time.Millisecond,
+	)
+}
+
 func assertVoidReceipt(t *testing.T, client *gateway.ReceiptsMock, id string) {
 	assert.EventuallyWithT(
 		t,
@@ -287,7 +458,7 @@ func assertReceiptForTicketIssued(t *testing.T, receiptsService *gateway.Receipt
 	require.Truef(t, ok, "receipt for ticket %s not found", ticket.TicketID)
 
 	assert.Equal(t, ticket.TicketID, receipt.TicketID)
-	assert.Equal(t, ticket.Price.Amount, receipt.Price.Amount)
+	assert.True(t, decimal.RequireFromString(ticket.Price.Amount).Equal(receipt.Price.Amount))
 	assert.Equal(t, ticket.Price.Currency, receipt.Price.Currency)
 }
 
@@ -320,6 +491,12 @@ type postShowsResponse struct {
 	ShowID string `json:"show_id"`
 }
 
+type showResponse struct {
+	ShowID           string `json:"show_id"`
+	NumberOfTickets  int    `json:"number_of_tickets"`
+	AvailableTickets int    `json:"available_tickets"`
+}
+
 type postBookTicketsRequest struct {
 	ShowID          string `json:"show_id"`
 	NumberOfTickets int    `json:"number_of_tickets"`
@@ -331,12 +508,13 @@ type postBookTicketsResponse struct {
 }
 
 type vipBundleRequest struct {
-	CustomerEmail   string   `json:"customer_email"`
-	InboundFlightID string   `json:"inbound_flight_id"`
-	NumberOfTickets int      `json:"number_of_tickets"`
-	Passengers      []string `json:"passengers"`
-	ReturnFlightID  string   `json:"return_flight_id"`
-	ShowID          string   `json:"show_id"`
+	CustomerEmail               string   `json:"customer_email"`
+	InboundFlightID             string   `json:"inbound_flight_id"`
+	AlternativeInboundFlightIDs []string `json:"alternative_inbound_flight_ids,omitempty"`
+	NumberOfTickets             int      `json:"number_of_tickets"`
+	Passengers                  []string `json:"passengers"`
+	ReturnFlightID              string   `json:"return_flight_id"`
+	ShowID                      string   `json:"show_id"`
 }
 
 type vipBundleResponse struct {
@@ -344,6 +522,19 @@ type vipBundleResponse struct {
 	VipBundleID string `json:"vip_bundle_id"`
 }
 
+type vipBundleStatusResponse struct {
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+	Timeline      []struct {
+		Step   string `json:"step"`
+		Status string `json:"status"`
+	} `json:"timeline"`
+	FlightAttempts []struct {
+		FlightID string `json:"flight_id"`
+		Status   string `json:"status"`
+	} `json:"flight_attempts"`
+}
+
 func sendTicketsStatus(t *testing.T, req TicketsStatusRequest, idempotencyKey string) {
 	t.Helper()
 
@@ -399,14 +590,131 @@ func sendPostShow(t *testing.T, request postShowsRequest) string {
 	return response.ShowID
 }
 
-func sentTicketRefund(t *testing.T, bookingID string) *http.Response {
+func sendPutShow(t *testing.T, showID string, request map[string]any) *http.Response {
+	t.Helper()
+
+	payload, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	httpReq, err := http.NewRequest(
+		http.MethodPut,
+		"http://localhost:8080/shows/"+showID,
+		bytes.NewBuffer(payload),
+	)
+	require.NoError(t, err)
+	httpReq.Header.Set("Correlation-ID", shortuuid.New())
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+
+	return resp
+}
+
+func sendCancelShow(t *testing.T, showID string) *http.Response {
+	t.Helper()
+
+	httpReq, err := http.NewRequest(
+		http.MethodPost,
+		"http://localhost:8080/shows/"+showID+"/cancel",
+		nil,
+	)
+	require.NoError(t, err)
+	httpReq.Header.Set("Correlation-ID", shortuuid.New())
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+
+	return resp
+}
+
+func assertShowCancellationCompleted(t *testing.T, showID string, ticketsRefunded int) {
+	assert.EventuallyWithT(
+		t,
+		func(t *assert.CollectT) {
+			resp, err := http.Get("http://localhost:8080/shows/" + showID + "/cancellation")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			var cancellation struct {
+				Status          string `json:"status"`
+				TicketsRefunded int    `json:"tickets_refunded"`
+			}
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&cancellation))
+			assert.Equal(t, "completed", cancellation.Status)
+			assert.Equal(t, ticketsRefunded, cancellation.TicketsRefunded)
+		},
+		10*time.Second,
+		100*time.Millisecond,
+	)
+}
+
+func assertTicketFileNotFound(t *testing.T, ticketID string) {
+	t.Helper()
+
+	resp, err := http.Get("http://localhost:8080/tickets/" + ticketID + "/file")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func getBooking(t *testing.T, bookingID string, customerEmail string) *http.Response {
+	t.Helper()
+
+	resp, err := http.Get("http://localhost:8080/bookings/" + bookingID + "?customer_email=" + url.QueryEscape(customerEmail))
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func assertTicketRefundCompleted(t *testing.T, ticketID string) {
+	assert.EventuallyWithT(
+		t,
+		func(t *assert.CollectT) {
+			resp, err := http.Get("http://localhost:8080/ticket-refund/" + ticketID)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			var refund struct {
+				Status string `json:"status"`
+			}
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&refund))
+			assert.Equal(t, "completed", refund.Status)
+		},
+		10*time.Second,
+		100*time.Millisecond,
+	)
+}
+
+func getShow(t *testing.T, showID string) showResponse {
+	t.Helper()
+
+	resp, err := http.Get("http://localhost:8080/shows/" + showID)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response showResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	require.NoError(t, err)
+	return response
+}
+
+func sentTicketRefund(t *testing.T, ticketID string) *http.Response {
 	t.Helper()
 
 	correlationID := shortuuid.New()
 
 	httpReq, err := http.NewRequest(
 		http.MethodPut,
-		"http://localhost:8080/ticket-refund/"+bookingID,
+		"http://localhost:8080/ticket-refund/"+ticketID,
 		nil,
 	)
 	httpReq.Header.Set("Correlation-ID", correlationID)
@@ -419,9 +727,30 @@ func sentTicketRefund(t *testing.T, bookingID string) *http.Response {
 	return resp
 }
 
+func cancelBooking(t *testing.T, bookingID string) *http.Response {
+	t.Helper()
+
+	httpReq, err := http.NewRequest(
+		http.MethodDelete,
+		"http://localhost:8080/bookings/"+bookingID,
+		nil,
+	)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+	return resp
+}
+
 func bookTickets(t *testing.T, request postBookTicketsRequest) *http.Response {
 	t.Helper()
 
+	return bookTicketsWithIdempotencyKey(t, request, "")
+}
+
+func bookTicketsWithIdempotencyKey(t *testing.T, request postBookTicketsRequest, idempotencyKey string) *http.Response {
+	t.Helper()
+
 	payload, err := json.Marshal(request)
 	require.NoError(t, err)
 
@@ -430,8 +759,11 @@ func bookTickets(t *testing.T, request postBookTicketsRequest) *http.Response {
 		"http://localhost:8080/book-tickets",
 		bytes.NewBuffer(payload),
 	)
-	httpReq.Header.Set("Content-Type", "application/json")
 	require.NoError(t, err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", idempotencyKey)
+	}
 
 	resp, err := http.DefaultClient.Do(httpReq)
 	require.NoError(t, err)
@@ -457,6 +789,20 @@ func sendBookVipBundle(t *testing.T, vb vipBundleRequest) *http.Response {
 	return resp
 }
 
+func getVipBundleStatus(t *testing.T, vipBundleID string) vipBundleStatusResponse {
+	t.Helper()
+
+	resp, err := http.Get("http://localhost:8080/vip-bundles/" + vipBundleID)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response vipBundleStatusResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	require.NoError(t, err)
+	return response
+}
+
 func waitForHttpServer(t *testing.T) {
 	t.Helper()
 